@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// publicTier — уровень доступа для запросов без ключа
+const publicTier = "public"
+
+// projectableFields — поля точки, которые можно скрыть (имена как в JSON-ответе).
+// Координаты не скрываются: без них точка бесполезна на карте.
+var projectableFields = map[string]func(p *LotPoint){
+	"lotName":        func(p *LotPoint) { p.LotName = "" },
+	"lotDescription": func(p *LotPoint) { p.LotDescription = "" },
+	"title":          func(p *LotPoint) { p.Title = "" },
+	"link":           func(p *LotPoint) { p.Link = "" },
+	"linkText":       func(p *LotPoint) { p.LinkText = "" },
+	"priority":       func(p *LotPoint) { p.Priority = 0 },
+	"category":       func(p *LotPoint) { p.Category = "" },
+	"subcategory":    func(p *LotPoint) { p.Subcategory = "" },
+	"region":         func(p *LotPoint) { p.Region = "" },
+	"status":         func(p *LotPoint) { p.Status = "" },
+	"weight":         func(p *LotPoint) { p.Weight = 0 },
+	"price":          func(p *LotPoint) { p.Price, p.Currency = nil, "" },
+	"thumbUrl":       func(p *LotPoint) { p.ThumbURL = "" },
+	"imageUrl":       func(p *LotPoint) { p.ImageURL = "" },
+	"extras":         func(p *LotPoint) { p.Extras = nil },
+	"createdAt":      func(p *LotPoint) { p.CreatedAt = nil },
+}
+
+// accessTier — набор полей, доступных владельцам ключей уровня; fields == nil — все поля.
+// drafts — уровню можно запрашивать черновики (includeDrafts=true).
+type accessTier struct {
+	name   string
+	fields map[string]bool
+	drafts bool
+}
+
+// accessControl — уровни доступа по API-ключам (ACCESS_TIERS). nil — без ограничений.
+type accessControl struct {
+	keys   map[string]*accessTier
+	public *accessTier
+}
+
+// parseAccessTiers — разбирает ACCESS_TIERS:
+//
+//	{"public": {"fields": ["lotName", "link"]}, "internal": {"keys": ["k1"], "fields": ["*"], "drafts": true}}
+//
+// Уровень "public" применяется к запросам без ключа; если его нет, такие запросы видят все поля.
+func parseAccessTiers(s string) (*accessControl, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	var cfg map[string]struct {
+		Keys   []string `json:"keys"`
+		Fields []string `json:"fields"`
+		Drafts bool     `json:"drafts"`
+	}
+	if err := json.Unmarshal([]byte(s), &cfg); err != nil {
+		return nil, fmt.Errorf("ожидается JSON-объект: %w", err)
+	}
+
+	ac := &accessControl{keys: make(map[string]*accessTier), public: &accessTier{name: publicTier}}
+	for name, t := range cfg {
+		tier := &accessTier{name: name, drafts: t.Drafts}
+		if !(len(t.Fields) == 1 && t.Fields[0] == "*") {
+			tier.fields = make(map[string]bool)
+			for _, f := range t.Fields {
+				if _, ok := projectableFields[f]; !ok {
+					return nil, fmt.Errorf("уровень %q: неизвестное поле %q", name, f)
+				}
+				tier.fields[f] = true
+			}
+		}
+		if name == publicTier {
+			if len(t.Keys) > 0 {
+				return nil, fmt.Errorf("уровню %q ключи не нужны", publicTier)
+			}
+			if t.Drafts {
+				return nil, fmt.Errorf("уровню %q черновики недоступны", publicTier)
+			}
+			ac.public = tier
+			continue
+		}
+		for _, k := range t.Keys {
+			if k == "" {
+				return nil, fmt.Errorf("уровень %q: пустой ключ", name)
+			}
+			if other, dup := ac.keys[k]; dup {
+				return nil, fmt.Errorf("ключ указан в уровнях %q и %q", other.name, name)
+			}
+			ac.keys[k] = tier
+		}
+	}
+	return ac, nil
+}
+
+// tierFor — уровень доступа запроса по заголовку X-API-Key или параметру apiKey.
+// ok=false — ключ передан, но неизвестен.
+func (ac *accessControl) tierFor(r *http.Request) (tier *accessTier, ok bool) {
+	if ac == nil {
+		return nil, true
+	}
+	key := r.Header.Get("X-API-Key")
+	if key == "" {
+		key = r.URL.Query().Get("apiKey")
+	}
+	if key == "" {
+		return ac.public, true
+	}
+	tier, ok = ac.keys[key]
+	return tier, ok
+}
+
+// setCacheControl — Cache-Control для ответа, отфильтрованного по уровню доступа. С ACCESS_TIERS
+// ответ на запрос с ключом общим кэшам (CDN, прокси) хранить нельзя: private. Vary: X-API-Key
+// не даёт отдать сохранённый публичный ответ запросу с ключом в заголовке; ключ в apiKey
+// виден общим кэшам и так — он часть адреса, но ответ на него тоже private.
+func (ac *accessControl) setCacheControl(w http.ResponseWriter, r *http.Request, maxAge int) {
+	scope := "public"
+	if ac != nil {
+		w.Header().Add("Vary", "X-API-Key")
+		if r.Header.Get("X-API-Key") != "" || r.URL.Query().Get("apiKey") != "" {
+			scope = "private"
+		}
+	}
+	w.Header().Set("Cache-Control", fmt.Sprintf("%s, max-age=%d", scope, maxAge))
+}
+
+// seesDrafts — уровню доступны черновики. Без ACCESS_TIERS ключей нет, и черновики не видит никто.
+func (t *accessTier) seesDrafts() bool {
+	return t != nil && t.drafts
+}
+
+// allows — поле доступно на этом уровне
+func (t *accessTier) allows(field string) bool {
+	return t == nil || t.fields == nil || t.fields[field]
+}
+
+// project — очищает в точках поля, недоступные на этом уровне (points — копия, изменяется на месте)
+func (t *accessTier) project(points []LotPoint) {
+	if t == nil || t.fields == nil {
+		return
+	}
+	var hidden []func(*LotPoint)
+	for field, clear := range projectableFields {
+		if !t.fields[field] {
+			hidden = append(hidden, clear)
+		}
+	}
+	for i := range points {
+		points[i].Raw = nil // исходная строка содержит все поля
+		for _, clear := range hidden {
+			clear(&points[i])
+		}
+	}
+}
+
+// writeUnknownKey — ответ на неизвестный API-ключ
+func writeUnknownKey(w http.ResponseWriter) {
+	writeAPIError(w, http.StatusUnauthorized, errCodeUnauthorized, "Неизвестный API-ключ", 0)
+}
+
+// tierNames — имена уровней доступа по алфавиту (для журнала при запуске)
+func (ac *accessControl) tierNames() []string {
+	seen := map[string]bool{ac.public.name: true}
+	for _, t := range ac.keys {
+		seen[t.name] = true
+	}
+	names := make([]string, 0, len(seen))
+	for n := range seen {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseFieldSet — разбирает список полей через запятую (параметр fields). lat и lon
+// допускаются, но ничего не меняют: координаты не скрываются.
+func parseFieldSet(s string) (map[string]bool, error) {
+	set := make(map[string]bool)
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if f == "lat" || f == "lon" {
+			continue
+		}
+		if _, ok := projectableFields[f]; !ok {
+			return nil, fmt.Errorf("неизвестное поле %q", f)
+		}
+		set[f] = true
+	}
+	return set, nil
+}
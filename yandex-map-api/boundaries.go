@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/orb/planar"
+)
+
+// boundary — административная граница из REGIONS_FILE
+type boundary struct {
+	name     string
+	geometry orb.Geometry // Polygon или MultiPolygon
+	bound    orb.Bound    // для быстрого отсева точек до проверки полигона
+	centroid orb.Point
+}
+
+// loadBoundaries — читает GeoJSON FeatureCollection с полигонами регионов; имя региона —
+// свойство nameProperty. Объекты другой геометрии и без имени — ошибка: файл готовится
+// заранее, и молча потерянный регион на карте заметят не сразу.
+func loadBoundaries(path, nameProperty string) ([]boundary, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fc, err := geojson.UnmarshalFeatureCollection(data)
+	if err != nil {
+		return nil, fmt.Errorf("ожидается GeoJSON FeatureCollection: %w", err)
+	}
+
+	var boundaries []boundary
+	seen := make(map[string]bool)
+	for i, f := range fc.Features {
+		name := f.Properties.MustString(nameProperty, "")
+		if name == "" {
+			return nil, fmt.Errorf("объект %d: нет свойства %q", i+1, nameProperty)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("регион %q встречается дважды", name)
+		}
+		seen[name] = true
+		switch f.Geometry.(type) {
+		case orb.Polygon, orb.MultiPolygon:
+		case nil:
+			return nil, fmt.Errorf("регион %q: нет геометрии", name)
+		default:
+			return nil, fmt.Errorf("регион %q: ожидается Polygon или MultiPolygon, получено %s", name, f.Geometry.GeoJSONType())
+		}
+		centroid, _ := planar.CentroidArea(f.Geometry)
+		boundaries = append(boundaries, boundary{name: name, geometry: f.Geometry, bound: f.Geometry.Bound(), centroid: centroid})
+	}
+	if len(boundaries) == 0 {
+		return nil, fmt.Errorf("в файле нет ни одного региона")
+	}
+	return boundaries, nil
+}
+
+// contains — точка внутри границы (на самой границе — тоже)
+func (b *boundary) contains(p orb.Point) bool {
+	if !b.bound.Contains(p) {
+		return false
+	}
+	switch g := b.geometry.(type) {
+	case orb.Polygon:
+		return planar.PolygonContains(g, p)
+	case orb.MultiPolygon:
+		return planar.MultiPolygonContains(g, p)
+	}
+	return false
+}
+
+// regionCount — число точек в регионе; Centroid — центр полигона для подписи (centroids=true)
+type regionCount struct {
+	Name     string      `json:"name"`
+	Count    int         `json:"count"`
+	Centroid *coordinate `json:"centroid,omitempty"`
+}
+
+type byRegionResponse struct {
+	Regions []regionCount `json:"regions"`
+	// Unassigned — точки вне всех регионов
+	Unassigned int `json:"unassigned"`
+	Total      int `json:"total"`
+}
+
+// byRegionHandler — GET /api/points/by-region[?centroids=true]: число точек в каждом регионе
+// из REGIONS_FILE (для картограмм). Регионы идут в порядке файла, пустые тоже; точка относится
+// к первому региону, который её содержит. Подсчёт повторяется только при обновлении данных.
+func byRegionHandler(cache *pointCache, boundaries []boundary) http.HandlerFunc {
+	var mu sync.Mutex
+	var counted *dataset
+	var counts []int
+	var unassigned int
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method != http.MethodGet {
+			http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+			return
+		}
+		withCentroids := r.URL.Query().Get("centroids") == "true"
+
+		data, err := cache.get(r.Context())
+		if err != nil {
+			writeLoadError(w, err)
+			return
+		}
+
+		mu.Lock()
+		if counted != data {
+			counts, unassigned = countByRegion(data.Points, boundaries)
+			counted = data
+		}
+		resp := byRegionResponse{Regions: make([]regionCount, len(boundaries)), Unassigned: unassigned, Total: len(data.Points)}
+		for i, b := range boundaries {
+			resp.Regions[i] = regionCount{Name: b.name, Count: counts[i]}
+			if withCentroids {
+				resp.Regions[i].Centroid = &coordinate{Lat: b.centroid.Lat(), Lon: b.centroid.Lon()}
+			}
+		}
+		mu.Unlock()
+
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			log.Printf("❌ Ошибка отправки JSON: %v", err)
+		}
+	}
+}
+
+// countByRegion — распределяет точки по регионам проверкой «точка в полигоне»
+func countByRegion(points []LotPoint, boundaries []boundary) (counts []int, unassigned int) {
+	counts = make([]int, len(boundaries))
+	for _, p := range points {
+		pt := orb.Point{p.Lon, p.Lat}
+		found := false
+		for i := range boundaries {
+			if boundaries[i].contains(pt) {
+				counts[i]++
+				found = true
+				break
+			}
+		}
+		if !found {
+			unassigned++
+		}
+	}
+	return counts, unassigned
+}
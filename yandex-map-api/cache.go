@@ -0,0 +1,346 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// pointCache — кэш разобранных точек. Обращение к Sheets происходит, только когда
+// данные старше ttl; устаревшие данные отдаются сразу, пока идёт одна общая загрузка.
+type pointCache struct {
+	load func(ctx context.Context) (*dataset, error)
+	ttl  time.Duration
+	// dedup — не увеличивать поколение, если таблица отдала те же самые точки
+	dedup bool
+	// minRefresh — не обращаться к Sheets чаще этого, как бы часто ни требовалось обновление
+	// (MIN_REFRESH_INTERVAL); в промежутке отдаётся текущее поколение
+	minRefresh time.Duration
+
+	refreshing chan struct{} // одна загрузка из Sheets за раз (семафор на один слот)
+	// startupWait — сколько ждать уже идущей первой загрузки, пока данных нет совсем
+	// (STARTUP_WAIT); дольше — 503 вместо повисшего запроса
+	startupWait time.Duration
+	// serveStale — устаревшие данные отдавать сразу, а таблицу перечитывать в фоне
+	// (по умолчанию); REFRESH_MODE=wait — запрос ждёт обновления
+	serveStale bool
+	// staleThreshold — после стольких времени без успешного чтения таблицы метрика data_stale
+	// становится 1 (STALE_THRESHOLD)
+	staleThreshold time.Duration
+	startedAt      time.Time
+	// minPoints и minFraction — защита от случайно опустошённой таблицы: новое чтение, где точек
+	// меньше minPoints или меньше minFraction от прежнего числа, отклоняется (см. checkSafety)
+	minPoints   int
+	minFraction float64
+
+	mu          sync.RWMutex
+	data        *dataset
+	refreshedAt time.Time
+	generation  uint64
+	hash        uint64
+	// fetchedAt и fetchErr — время и результат последнего обращения к Sheets, в том числе неудачного
+	fetchedAt time.Time
+	fetchErr  error
+	// rejectedAt — когда чтение последний раз отклонено защитой (точки прежние, но до следующей
+	// попытки ждём ttl, чтобы не перечитывать таблицу на каждом запросе)
+	rejectedAt time.Time
+
+	// Пустая таблица — не сбой: считаем её отдельно от ошибок, чтобы тревожить только по ошибкам
+	emptyLoads      *counter
+	sheetsErrors    *counter
+	refreshRejected *counter
+}
+
+// Состояние последнего чтения таблицы (cacheStatus.Sheets)
+const (
+	sheetsStateOK    = "ok"
+	sheetsStateEmpty = "empty" // таблица доступна, но валидных точек нет
+	sheetsStateError = "error" // таблица недоступна или ответила ошибкой
+)
+
+// cacheStatus — состояние кэша для /health/detail
+type cacheStatus struct {
+	RefreshedAt *time.Time `json:"refreshedAt,omitempty"`
+	AgeSeconds  float64    `json:"ageSeconds"`
+	Generation  uint64     `json:"generation"`
+	Points      int        `json:"points"`
+	Stale       bool       `json:"stale"`
+	TTLSeconds  float64    `json:"ttlSeconds"`
+	// Sheets — итог последнего чтения: ok, empty или error ("" — ещё не читали)
+	Sheets    string `json:"sheets,omitempty"`
+	LastError string `json:"lastError,omitempty"`
+}
+
+func newPointCache(ttl time.Duration, dedup bool, load func(ctx context.Context) (*dataset, error)) *pointCache {
+	c := &pointCache{
+		load: load, ttl: ttl, dedup: dedup,
+		refreshing:     make(chan struct{}, 1),
+		serveStale:     true,
+		staleThreshold: max(3*ttl, time.Minute),
+		startedAt:      time.Now(),
+		emptyLoads:     metrics.counter("points_empty_total", "Чтения таблицы без единой валидной точки"),
+		sheetsErrors:   metrics.counter("sheets_error_total", "Неудачные чтения таблицы"),
+		refreshRejected: metrics.counter("refresh_rejected_total",
+			"Чтения таблицы, отклонённые защитой MIN_POINTS_THRESHOLD / MIN_POINTS_FRACTION"),
+	}
+	metrics.gauge("refresh_rejected", "1 — последнее чтение таблицы отклонено защитой, отдаются прежние точки", func() float64 {
+		c.mu.RLock()
+		defer c.mu.RUnlock()
+		if !c.rejectedAt.IsZero() && c.rejectedAt.After(c.refreshedAt) {
+			return 1
+		}
+		return 0
+	})
+	metrics.gauge("data_age_seconds", "Секунд с последнего успешного чтения таблицы", func() float64 {
+		return c.dataAge().Seconds()
+	})
+	metrics.gauge("data_stale", "1 — таблица не читалась успешно дольше STALE_THRESHOLD", func() float64 {
+		if c.dataAge() > c.staleThreshold {
+			return 1
+		}
+		return 0
+	})
+	return c
+}
+
+// dataAge — сколько прошло с последнего успешного чтения таблицы (до первого — с запуска).
+// Неудачные попытки возраст не сбрасывают: свежесть кэша тут ни при чём, важно, что данные
+// действительно обновились.
+func (c *pointCache) dataAge() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.refreshedAt.IsZero() {
+		return time.Since(c.startedAt)
+	}
+	return time.Since(c.refreshedAt)
+}
+
+// fresh — данные загружены и ещё не устарели (вызывать под c.mu).
+// Отклонённое защитой чтение тоже откладывает следующее на ttl.
+func (c *pointCache) fresh(now time.Time) bool {
+	last := c.refreshedAt
+	if c.rejectedAt.After(last) {
+		last = c.rejectedAt
+	}
+	return !c.refreshedAt.IsZero() && now.Sub(last) < c.ttl
+}
+
+// get — возвращает данные, при необходимости перечитывая таблицу.
+// Возвращаемый набор общий для всех запросов: изменять его нельзя.
+//
+// Запрос, пришедший во время обновления, получает целиком либо прежний набор, либо новый:
+// набор заменяется одним присваиванием под c.mu после полной загрузки. С serveStale
+// (по умолчанию) устаревший набор отдаётся без ожидания, а обновление идёт в фоне; ждать
+// приходится, только пока данных нет совсем.
+func (c *pointCache) get(ctx context.Context) (*dataset, error) {
+	c.mu.RLock()
+	data := c.data
+	fresh := c.fresh(time.Now())
+	c.mu.RUnlock()
+	if fresh {
+		return data, nil
+	}
+
+	if c.serveStale && data != nil {
+		select {
+		case c.refreshing <- struct{}{}:
+			go c.refreshInBackground()
+		default: // обновление уже идёт
+		}
+		return data, nil
+	}
+
+	if err := c.acquireRefresh(ctx); err != nil {
+		return nil, err
+	}
+	defer func() { <-c.refreshing }()
+	return c.refresh(ctx)
+}
+
+// refreshInBackground — обновление для serveStale; право на загрузку уже занято вызывающим
+func (c *pointCache) refreshInBackground() {
+	defer func() { <-c.refreshing }()
+	ctx, cancel := context.WithTimeout(context.Background(), warmupTimeout)
+	defer cancel()
+	c.refresh(ctx)
+}
+
+// refresh — перечитывает таблицу, если данные всё ещё устарели (вызывать, заняв c.refreshing)
+func (c *pointCache) refresh(ctx context.Context) (*dataset, error) {
+	// Пока ждали, данные мог обновить другой запрос
+	c.mu.RLock()
+	if c.fresh(time.Now()) {
+		data := c.data
+		c.mu.RUnlock()
+		return data, nil
+	}
+	// Защита квоты: слишком рано для нового чтения — отдаём то, что есть
+	if c.minRefresh > 0 && !c.fetchedAt.IsZero() && time.Since(c.fetchedAt) < c.minRefresh {
+		data, err := c.data, c.fetchErr
+		c.mu.RUnlock()
+		if data != nil {
+			return data, nil
+		}
+		return nil, err
+	}
+	c.mu.RUnlock()
+
+	data, err := c.reload(ctx, false)
+	var rejected *refreshRejectedError
+	if errors.As(err, &rejected) && data != nil {
+		return data, nil
+	}
+	return data, err
+}
+
+// reload — читает таблицу и заменяет данные кэша (вызывать, заняв c.refreshing).
+// Без force новое чтение проходит checkSafety; отклонённое возвращает прежние данные
+// вместе с *refreshRejectedError.
+func (c *pointCache) reload(ctx context.Context, force bool) (*dataset, error) {
+	data, err := c.load(ctx)
+	if ctx.Err() == nil { // прерванный клиентом запрос не считается попыткой
+		c.mu.Lock()
+		c.fetchedAt, c.fetchErr = time.Now(), err
+		c.mu.Unlock()
+	}
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			c.sheetsErrors.Inc()
+			log.Printf("❌ Таблица недоступна или ответила ошибкой: %v", err)
+		}
+		return nil, err
+	}
+	if len(data.Points) == 0 {
+		c.emptyLoads.Inc()
+		log.Printf("ℹ️ Таблица прочитана, но валидных точек нет (проблемных строк: %d)", len(data.Issues))
+	}
+
+	c.mu.RLock()
+	prev := c.data
+	c.mu.RUnlock()
+	if err := c.checkSafety(prev, data); err != nil {
+		if !force {
+			c.refreshRejected.Inc()
+			log.Printf("❌ Новые данные отклонены, отдаём прежние %d точек: %v", len(prev.Points), err)
+			c.mu.Lock()
+			c.rejectedAt, c.fetchErr = time.Now(), err
+			c.mu.Unlock()
+			return prev, err
+		}
+		log.Printf("⚠️ Защита от опустошения таблицы снята (force): %v", err)
+	}
+
+	// Правка черновика — тоже изменение данных: редакторы ждут его в предпросмотре
+	hash := hashPoints(append(append([]LotPoint(nil), data.Points...), data.Drafts...))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.data = data
+	c.refreshedAt = time.Now()
+	if c.dedup && c.generation > 0 && hash == c.hash {
+		// Таблицу сохранили без изменения данных лотов — поколение не трогаем
+		log.Printf("ℹ️ Данные не изменились, поколение %d сохранено", c.generation)
+		return data, nil
+	}
+	c.hash = hash
+	c.generation++
+	return data, nil
+}
+
+// errStillLoading — первая загрузка не успела завершиться за STARTUP_WAIT
+var errStillLoading = &loadError{http.StatusServiceUnavailable, "Данные ещё загружаются, повторите запрос позже", nil}
+
+// acquireRefresh — занимает право на загрузку. Пока данных нет и действует startupWait,
+// ждём чужую загрузку не дольше него; в остальных случаях — сколько позволит контекст запроса.
+func (c *pointCache) acquireRefresh(ctx context.Context) error {
+	c.mu.RLock()
+	empty := c.data == nil
+	c.mu.RUnlock()
+
+	var timeout <-chan time.Time
+	if empty && c.startupWait > 0 {
+		t := time.NewTimer(c.startupWait)
+		defer t.Stop()
+		timeout = t.C
+	}
+	select {
+	case c.refreshing <- struct{}{}:
+		return nil
+	case <-timeout:
+		log.Printf("⚠️ Первая загрузка не завершилась за %v, отвечаем 503", c.startupWait)
+		return errStillLoading
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// sheetsState — итог последнего чтения таблицы (вызывать под c.mu)
+func (c *pointCache) sheetsState() string {
+	switch {
+	case c.fetchedAt.IsZero():
+		return ""
+	case c.fetchErr != nil:
+		return sheetsStateError
+	case c.pointCount() == 0:
+		return sheetsStateEmpty
+	}
+	return sheetsStateOK
+}
+
+// hashPoints — хэш сериализованных точек без исходных строк (Raw): правки
+// в посторонних колонках не считаются изменением данных
+func hashPoints(points []LotPoint) uint64 {
+	h := fnv.New64a()
+	enc := json.NewEncoder(h)
+	for _, p := range points {
+		p.Raw = nil
+		enc.Encode(p)
+	}
+	return h.Sum64()
+}
+
+// pointHash — FNV-1a от значений полей точки (hex). Нужна только чтобы заметить,
+// что точка изменилась между запросами; для защиты от подделки не годится.
+func pointHash(p LotPoint) string {
+	p.Raw, p.Hash = nil, ""
+	h := fnv.New64a()
+	json.NewEncoder(h).Encode(p)
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+func (c *pointCache) status() cacheStatus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := time.Now()
+	st := cacheStatus{
+		Generation: c.generation,
+		Points:     c.pointCount(),
+		Stale:      !c.fresh(now),
+		TTLSeconds: c.ttl.Seconds(),
+	}
+	if !c.refreshedAt.IsZero() {
+		t := c.refreshedAt
+		st.RefreshedAt = &t
+		st.AgeSeconds = now.Sub(t).Seconds()
+	}
+	st.Sheets = c.sheetsState()
+	if c.fetchErr != nil {
+		st.LastError = c.fetchErr.Error()
+	}
+	return st
+}
+
+// pointCount — число точек в кэше (вызывать под c.mu)
+func (c *pointCache) pointCount() int {
+	if c.data == nil {
+		return 0
+	}
+	return len(c.data.Points)
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// snapshot — набор из n точек, помеченных поколением gen (в LotName)
+func snapshot(gen, n int) *dataset {
+	points := make([]LotPoint, n)
+	for i := range points {
+		points[i] = LotPoint{Lat: 55, Lon: 49, LotName: "gen" + strconv.Itoa(gen), ID: strconv.Itoa(i)}
+	}
+	return &dataset{Points: points}
+}
+
+// checkSnapshot — набор целиком из одного поколения и полного размера; возвращает поколение
+// (t.Errorf, а не Fatalf: вызывается из горутин читателей)
+func checkSnapshot(t *testing.T, data *dataset, n int) string {
+	t.Helper()
+	if len(data.Points) != n {
+		t.Errorf("в наборе %d точек, ожидалось %d", len(data.Points), n)
+		return ""
+	}
+	gen := data.Points[0].LotName
+	for _, p := range data.Points {
+		if p.LotName != gen {
+			t.Errorf("в одном наборе точки поколений %s и %s", gen, p.LotName)
+			return ""
+		}
+	}
+	return gen
+}
+
+func TestPointCacheServesStaleDuringSlowRefresh(t *testing.T) {
+	const n = 100
+	release := make(chan struct{})
+	entered := make(chan struct{}, 1) // фоновое обновление вызвало загрузку
+	var loads atomic.Int32
+	cache := newPointCache(time.Millisecond, false, func(ctx context.Context) (*dataset, error) {
+		if loads.Add(1) == 1 {
+			return snapshot(1, n), nil
+		}
+		select {
+		case entered <- struct{}{}:
+		default:
+		}
+		select {
+		case <-release: // медленное обновление: ждёт, пока тест его не отпустит
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		return snapshot(2, n), nil
+	})
+
+	if _, err := cache.get(context.Background()); err != nil {
+		t.Fatalf("первая загрузка: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond) // данные устарели
+
+	// Первый запрос к устаревшим данным запускает фоновое обновление; дожидаемся, пока оно
+	// действительно повиснет в загрузке, — только тогда проверка читателей что-то доказывает
+	if data, err := cache.get(context.Background()); err != nil || checkSnapshot(t, data, n) != "gen1" {
+		t.Fatalf("запрос к устаревшим данным: %v", err)
+	}
+	select {
+	case <-entered:
+	case <-time.After(2 * time.Second):
+		t.Fatal("фоновое обновление так и не началось")
+	}
+
+	// Пока обновление висит, ни один читатель не должен ждать его
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+				data, err := cache.get(ctx)
+				cancel()
+				if err != nil {
+					t.Errorf("читатель заблокирован обновлением: %v", err)
+					return
+				}
+				if gen := checkSnapshot(t, data, n); gen != "gen1" {
+					t.Errorf("до завершения обновления получено %s", gen)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if got := loads.Load(); got != 2 {
+		t.Fatalf("обращений к таблице %d, ожидалось 2 (одно фоновое обновление)", got)
+	}
+
+	// Во время замены набора читатели видят либо прежний, либо новый целиком
+	close(release)
+	seenNew := make(chan struct{})
+	var once sync.Once
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			deadline := time.Now().Add(2 * time.Second)
+			for time.Now().Before(deadline) {
+				data, err := cache.get(context.Background())
+				if err != nil {
+					t.Errorf("get: %v", err)
+					return
+				}
+				switch checkSnapshot(t, data, n) {
+				case "":
+					return
+				case "gen2":
+					once.Do(func() { close(seenNew) })
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	select {
+	case <-seenNew:
+	default:
+		t.Fatal("новый набор так и не появился")
+	}
+}
+
+func TestPointCacheWaitModeBlocksOnStale(t *testing.T) {
+	release := make(chan struct{})
+	var loads atomic.Int32
+	cache := newPointCache(time.Millisecond, false, func(ctx context.Context) (*dataset, error) {
+		if loads.Add(1) == 1 {
+			return snapshot(1, 1), nil
+		}
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		return snapshot(2, 1), nil
+	})
+	cache.serveStale = false
+
+	if _, err := cache.get(context.Background()); err != nil {
+		t.Fatalf("первая загрузка: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := cache.get(ctx); err == nil {
+		t.Fatal("REFRESH_MODE=wait: запрос не дождался обновления")
+	}
+	close(release)
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+const (
+	// defaultClusterCellPx — размер ячейки кластеризации в пикселях при тайлах 256×256
+	defaultClusterCellPx = 60
+	tileSizePx           = 256
+)
+
+// coordinate — точка в ответах с вычисленными координатами
+type coordinate struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// pointCluster — группа близких точек на данном масштабе.
+// Center — центр ячейки сетки, Centroid — центр масс точек с учётом колонки Weight
+// (без неё — среднее): метку кластера лучше ставить в Centroid, чтобы она была там, где точки.
+type pointCluster struct {
+	Center   coordinate `json:"center"`
+	Centroid coordinate `json:"centroid"`
+	Count    int        `json:"count"`
+	Weight   float64    `json:"weight"`
+	Bounds   bbox       `json:"bounds"`
+	// Point — сама точка, если она в кластере одна
+	Point *LotPoint `json:"point,omitempty"`
+}
+
+type clustersResponse struct {
+	Zoom     int            `json:"zoom"`
+	CellPx   int            `json:"cellPx"`
+	Clusters []pointCluster `json:"clusters"`
+}
+
+// clustersHandler — GET /api/points/clusters?zoom=Z[&bbox=minLon,minLat,maxLon,maxLat][&cell=px]
+// Сеточная кластеризация: точки в одной ячейке размером cell пикселей на масштабе zoom
+// объединяются. Кластеры упорядочены по убыванию размера.
+func clustersHandler(cache *pointCache, access *accessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method != http.MethodGet {
+			http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+			return
+		}
+
+		tier, ok := access.tierFor(r)
+		if !ok {
+			writeUnknownKey(w)
+			return
+		}
+
+		q := r.URL.Query()
+		zoom, err := strconv.Atoi(q.Get("zoom"))
+		if err != nil || zoom < 0 || zoom > maxTileZoom {
+			http.Error(w, "Требуется параметр zoom от 0 до "+strconv.Itoa(maxTileZoom), http.StatusBadRequest)
+			return
+		}
+		cellPx := defaultClusterCellPx
+		if v := q.Get("cell"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 10 || n > tileSizePx {
+				http.Error(w, "Некорректный параметр cell (10–256)", http.StatusBadRequest)
+				return
+			}
+			cellPx = n
+		}
+
+		var area bbox
+		hasArea := false
+		if v := q.Get("bbox"); v != "" {
+			b, err := parseBBox(v)
+			if err != nil {
+				http.Error(w, "Некорректный параметр bbox: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			area, hasArea = b, true
+		}
+
+		data, err := cache.get(r.Context())
+		if err != nil {
+			writeLoadError(w, err)
+			return
+		}
+
+		points := make([]LotPoint, 0, len(data.Points))
+		for _, p := range data.Points {
+			if hasArea && !area.contains(p.Lat, p.Lon) {
+				continue
+			}
+			p.Raw = nil
+			points = append(points, p)
+		}
+		tier.project(points)
+
+		resp := clustersResponse{Zoom: zoom, CellPx: cellPx, Clusters: clusterPoints(points, zoom, cellPx)}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			log.Printf("❌ Ошибка отправки JSON: %v", err)
+		}
+	}
+}
+
+// clusterPoints — раскладывает точки по ячейкам сетки. Шаг сетки в градусах соответствует
+// cellPx пикселям на масштабе zoom (по долготе; по широте берётся тот же шаг — для
+// кластеризации этого достаточно).
+func clusterPoints(points []LotPoint, zoom, cellPx int) []pointCluster {
+	cellDeg := 360 / (float64(tileSizePx) * math.Exp2(float64(zoom))) * float64(cellPx)
+
+	type acc struct {
+		cluster          pointCluster
+		sumLat, sumLon   float64
+		first            LotPoint
+		cellLat, cellLon float64
+	}
+	index := make(map[gridCell]*acc)
+	var order []gridCell
+	for _, p := range points {
+		c := gridCell{int(math.Floor(p.Lon / cellDeg)), int(math.Floor(p.Lat / cellDeg))}
+		a, ok := index[c]
+		if !ok {
+			a = &acc{first: p, cellLon: float64(c.x) * cellDeg, cellLat: float64(c.y) * cellDeg}
+			a.cluster.Bounds = bbox{p.Lon, p.Lat, p.Lon, p.Lat}
+			index[c] = a
+			order = append(order, c)
+		}
+		wgt := p.Weight
+		if wgt <= 0 {
+			wgt = 1
+		}
+		a.cluster.Count++
+		a.cluster.Weight += wgt
+		a.sumLat += p.Lat * wgt
+		a.sumLon += p.Lon * wgt
+		b := &a.cluster.Bounds
+		b[0], b[1] = math.Min(b[0], p.Lon), math.Min(b[1], p.Lat)
+		b[2], b[3] = math.Max(b[2], p.Lon), math.Max(b[3], p.Lat)
+	}
+
+	clusters := make([]pointCluster, 0, len(order))
+	for _, c := range order {
+		a := index[c]
+		cl := a.cluster
+		cl.Center = coordinate{
+			Lat: math.Max(-90, math.Min(90, a.cellLat+cellDeg/2)),
+			Lon: math.Max(-180, math.Min(180, a.cellLon+cellDeg/2)),
+		}
+		cl.Centroid = coordinate{Lat: a.sumLat / cl.Weight, Lon: a.sumLon / cl.Weight}
+		if cl.Count == 1 {
+			p := a.first
+			cl.Point = &p
+		}
+		clusters = append(clusters, cl)
+	}
+	sort.SliceStable(clusters, func(i, j int) bool { return clusters[i].Count > clusters[j].Count })
+	return clusters
+}
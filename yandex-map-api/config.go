@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// frontendConfig — настройки карты для фронтенда (GET /api/config). Сюда попадает только то,
+// что и так видно в браузере: ключ JavaScript API Яндекс.Карт публичный по своей природе
+// (его ограничивают по HTTP Referer в кабинете разработчика). Поля перечислены явно,
+// чтобы ни одна серверная переменная окружения не утекла в ответ случайно.
+type frontendConfig struct {
+	YandexAPIKey string     `json:"yandexApiKey,omitempty"`
+	Center       [2]float64 `json:"center"` // [lat, lon], как в ymaps.Map
+	Zoom         int        `json:"zoom"`
+	// Layers — типы карты: первый — по умолчанию, при нескольких показывается переключатель
+	Layers []string `json:"layers"`
+}
+
+// yandexMapLayers — типы карты Яндекса, которые можно включить в MAP_LAYERS
+var yandexMapLayers = []string{"yandex#map", "yandex#satellite", "yandex#hybrid"}
+
+// parseFrontendConfig — собирает настройки из MAP_YANDEX_API_KEY, MAP_CENTER ("55.83,49.07"),
+// MAP_ZOOM и MAP_LAYERS ("yandex#map,yandex#satellite"); по умолчанию — Казань, масштаб 10
+func parseFrontendConfig() (frontendConfig, error) {
+	cfg := frontendConfig{
+		YandexAPIKey: strings.TrimSpace(os.Getenv("MAP_YANDEX_API_KEY")),
+		Center:       [2]float64{55.830431, 49.066143},
+		Zoom:         10,
+		Layers:       []string{"yandex#map"},
+	}
+	if v := os.Getenv("MAP_CENTER"); v != "" {
+		lat, lon, err := parseCoordString(v)
+		if err != nil || !validLatLon(lat, lon) {
+			return cfg, fmt.Errorf("MAP_CENTER: ожидается \"широта,долгота\", получено %q", v)
+		}
+		cfg.Center = [2]float64{lat, lon}
+	}
+	if v := os.Getenv("MAP_ZOOM"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > 21 {
+			return cfg, fmt.Errorf("MAP_ZOOM: ожидается число от 0 до 21, получено %q", v)
+		}
+		cfg.Zoom = n
+	}
+	if v := os.Getenv("MAP_LAYERS"); v != "" {
+		cfg.Layers = nil
+		for _, layer := range strings.Split(v, ",") {
+			layer = strings.TrimSpace(layer)
+			if layer == "" || slices.Contains(cfg.Layers, layer) {
+				continue
+			}
+			if !slices.Contains(yandexMapLayers, layer) {
+				return cfg, fmt.Errorf("MAP_LAYERS: неизвестный тип карты %q (допустимо: %s)", layer, strings.Join(yandexMapLayers, ", "))
+			}
+			cfg.Layers = append(cfg.Layers, layer)
+		}
+		if len(cfg.Layers) == 0 {
+			return cfg, fmt.Errorf("MAP_LAYERS: не задано ни одного типа карты")
+		}
+	}
+	return cfg, nil
+}
+
+// configHandler — GET /api/config: настройки карты, чтобы не держать их в коде страницы
+func configHandler(cfg frontendConfig) http.HandlerFunc {
+	body, _ := json.Marshal(cfg)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		// Настройки меняются только с перезапуском — браузер может их не перезапрашивать
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		if _, err := w.Write(append(body, '\n')); err != nil {
+			log.Printf("❌ Ошибка отправки JSON: %v", err)
+		}
+	}
+}
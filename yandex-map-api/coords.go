@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Источники координат строки (COORD_SOURCE_PRIORITY)
+const (
+	coordSourcePoint   = "point"   // Lot_info: {"point": {"lat": .., "lon": ..}}
+	coordSourceArray   = "array"   // Lot_info: {"coordinates": [lat, lon]} — порядок как в Яндекс.Картах
+	coordSourceColumns = "columns" // числовые колонки Lat и Lon
+	coordSourceString  = "string"  // колонка Coordinates: "55.75, 37.61"
+	coordSourceRegion  = "region"  // центр региона из колонки Region (только с APPROXIMATE_PLACEMENT)
+)
+
+// defaultCoordPriority — порядок по умолчанию: явно заданная точка важнее производных источников
+var defaultCoordPriority = []string{coordSourcePoint, coordSourceArray, coordSourceColumns, coordSourceString, coordSourceRegion}
+
+// parseCoordPriority — разбирает COORD_SOURCE_PRIORITY: источники через запятую в порядке
+// убывания приоритета. Не перечисленные источники не используются.
+func parseCoordPriority(s string) ([]string, error) {
+	known := make(map[string]bool, len(defaultCoordPriority))
+	for _, src := range defaultCoordPriority {
+		known[src] = true
+	}
+	var order []string
+	seen := make(map[string]bool)
+	for _, src := range strings.Split(s, ",") {
+		src = strings.ToLower(strings.TrimSpace(src))
+		switch {
+		case src == "":
+			continue
+		case src == "geocode":
+			return nil, fmt.Errorf("геокодирование адресов не поддерживается")
+		case !known[src]:
+			return nil, fmt.Errorf("неизвестный источник %q (допустимо: %s)", src, strings.Join(defaultCoordPriority, ", "))
+		case seen[src]:
+			return nil, fmt.Errorf("источник %q указан дважды", src)
+		}
+		seen[src] = true
+		order = append(order, src)
+	}
+	if len(order) == 0 {
+		return nil, fmt.Errorf("пустой список источников")
+	}
+	return order, nil
+}
+
+// coordResult — найденные координаты и источник, из которого они взяты
+type coordResult struct {
+	lat, lon float64
+	source   string
+}
+
+// resolveCoordinates — координаты строки из первого по приоритету источника, где они есть.
+// Некорректные значения в колонках сообщаются через report и считаются отсутствующими.
+// При autoFixCoordOrder перепутанные широта и долгота меняются местами, если иначе
+// широта вне диапазона, а после обмена обе координаты допустимы.
+func (l *sheetLoader) resolveCoordinates(lot LotInfo, row []interface{}, rowNum int, cols columnIndexes, region string,
+	report func(problem, message, value string, skipped bool)) (coordResult, bool) {
+	order := l.coordPriority
+	if order == nil {
+		order = defaultCoordPriority
+	}
+	for _, src := range order {
+		var lat, lon float64
+		found := false
+		switch src {
+		case coordSourcePoint:
+			lat, lon = lot.Point.Lat, lot.Point.Lon
+			found = lat != 0 || lon != 0
+		case coordSourceArray:
+			if len(lot.Coordinates) == 2 {
+				lat, lon, found = lot.Coordinates[0], lot.Coordinates[1], true
+			}
+		case coordSourceColumns:
+			latStr := strings.TrimSpace(cellToString(cellAt(row, cols.lat)))
+			lonStr := strings.TrimSpace(cellToString(cellAt(row, cols.lon)))
+			if latStr == "" || lonStr == "" {
+				continue
+			}
+			var err1, err2 error
+			lat, err1 = parseCoordNumber(latStr)
+			lon, err2 = parseCoordNumber(lonStr)
+			if err1 != nil || err2 != nil {
+				report(issueInvalidValue, "Некорректные координаты в колонках Lat/Lon", latStr+", "+lonStr, false)
+				continue
+			}
+			found = true
+		case coordSourceString:
+			s := strings.TrimSpace(cellToString(cellAt(row, cols.coords)))
+			if s == "" {
+				continue
+			}
+			var err error
+			lat, lon, err = parseCoordString(s)
+			if err != nil {
+				report(issueInvalidValue, "Некорректные координаты в колонке Coordinates", s, false)
+				continue
+			}
+			found = true
+		case coordSourceRegion:
+			if !l.approximatePlacement || region == "" {
+				continue
+			}
+			var c latLon
+			c, found = regionCentroid(region)
+			lat, lon = c.lat, c.lon
+		}
+		if !found {
+			continue
+		}
+		if l.autoFixCoordOrder && !validLatLon(lat, lon) && validLatLon(lon, lat) {
+			log.Printf("⚠️ Строка %d: широта и долгота перепутаны (%g, %g), меняем местами", rowNum, lat, lon)
+			report(issueInvalidValue, "Широта и долгота перепутаны, исправлено (источник "+src+")",
+				fmt.Sprintf("%g, %g", lat, lon), false)
+			lat, lon = lon, lat
+		}
+		if !validLatLon(lat, lon) {
+			report(issueInvalidValue, "Координаты вне допустимого диапазона (источник "+src+")",
+				fmt.Sprintf("%g, %g", lat, lon), false)
+			continue
+		}
+		return coordResult{lat, lon, src}, true
+	}
+	return coordResult{}, false
+}
+
+// validLatLon — координаты в допустимом диапазоне
+func validLatLon(lat, lon float64) bool {
+	return lat >= -90 && lat <= 90 && lon >= -180 && lon <= 180
+}
+
+// parseCoordNumber — число с точкой или запятой в качестве десятичного разделителя.
+// Экспоненциальная запись (5.575e1, 5,575E+01 — так Sheets иногда отдаёт очень точные
+// значения) понимается ParseFloat; NaN и бесконечности координатами не считаются.
+func parseCoordNumber(s string) (float64, error) {
+	f, err := strconv.ParseFloat(strings.Replace(strings.TrimSpace(s), ",", ".", 1), 64)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, fmt.Errorf("некорректное число %q", s)
+	}
+	return f, nil
+}
+
+// parseCoordString — "lat, lon", "lat lon" или "lat; lon"
+func parseCoordString(s string) (lat, lon float64, err error) {
+	var parts []string
+	switch {
+	case strings.Contains(s, ";"):
+		parts = strings.Split(s, ";")
+	case strings.Count(s, ",") == 1 && !strings.Contains(strings.TrimSpace(s), " "):
+		parts = strings.Split(s, ",")
+	case strings.Contains(s, ", "):
+		parts = strings.SplitN(s, ", ", 2)
+	default:
+		parts = strings.Fields(s)
+	}
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("ожидается два числа")
+	}
+	if lat, err = parseCoordNumber(parts[0]); err != nil {
+		return 0, 0, err
+	}
+	if lon, err = parseCoordNumber(parts[1]); err != nil {
+		return 0, 0, err
+	}
+	return lat, lon, nil
+}
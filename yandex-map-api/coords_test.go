@@ -0,0 +1,57 @@
+package main
+
+import "testing"
+
+func TestParseCoordNumber(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    float64
+		wantErr bool
+	}{
+		{in: "55.830431", want: 55.830431},
+		{in: "55,830431", want: 55.830431},
+		{in: "  -33.9 ", want: -33.9},
+		{in: "49", want: 49},
+		// Экспоненциальная запись, как её иногда отдаёт Sheets
+		{in: "5.57e1", want: 55.7},
+		{in: "5,575E+01", want: 55.75},
+		{in: "4.9066143e1", want: 49.066143},
+		{in: "-1.5e-3", want: -0.0015},
+		{in: "", wantErr: true},
+		{in: "55.8.3", wantErr: true},
+		{in: "NaN", wantErr: true},
+		{in: "Inf", wantErr: true},
+		{in: "1e400", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseCoordNumber(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseCoordNumber(%q) = %v, ожидалась ошибка", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parseCoordNumber(%q) = %v, %v; ожидалось %v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestParseCoordString(t *testing.T) {
+	tests := []struct {
+		in       string
+		lat, lon float64
+	}{
+		{"55.83, 49.07", 55.83, 49.07},
+		{"55.83,49.07", 55.83, 49.07},
+		{"55,83; 49,07", 55.83, 49.07},
+		{"55.83 49.07", 55.83, 49.07},
+		{"5.583e1, 4.907e1", 55.83, 49.07},
+	}
+	for _, tt := range tests {
+		lat, lon, err := parseCoordString(tt.in)
+		if err != nil || lat != tt.lat || lon != tt.lon {
+			t.Errorf("parseCoordString(%q) = %v, %v, %v; ожидалось %v, %v", tt.in, lat, lon, err, tt.lat, tt.lon)
+		}
+	}
+}
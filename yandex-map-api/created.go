@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// createdAtLayouts — форматы даты в колонке Created_at (как её показывает Sheets и как её
+// вводят вручную). Дата без часового пояса считается в UTC.
+var createdAtLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"02.01.2006 15:04:05",
+	"02.01.2006 15:04",
+	"02.01.2006",
+}
+
+// parseCreatedAt — разбирает дату добавления лота
+func parseCreatedAt(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range createdAtLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("неизвестный формат даты %q", s)
+}
+
+// parseFrame — момент кадра анимации (frame): дата или дата со временем. Для даты без
+// времени кадр включает весь день.
+func parseFrame(s string) (time.Time, error) {
+	t, err := parseCreatedAt(s)
+	if err != nil {
+		return t, err
+	}
+	if !strings.ContainsAny(strings.TrimSpace(s), ": T") {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+	return t, nil
+}
+
+// createdAtRange — самая ранняя и самая поздняя даты добавления (ok=false — дат нет).
+// Клиенту они нужны, чтобы построить шкалу кадров.
+func createdAtRange(points []LotPoint) (first, last time.Time, ok bool) {
+	for _, p := range points {
+		if p.CreatedAt == nil {
+			continue
+		}
+		if !ok || p.CreatedAt.Before(first) {
+			first = *p.CreatedAt
+		}
+		if !ok || p.CreatedAt.After(last) {
+			last = *p.CreatedAt
+		}
+		ok = true
+	}
+	return first, last, ok
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"strings"
+)
+
+// serviceAccountKey — поля ключа сервисного аккаунта, без которых клиент Sheets не создать
+type serviceAccountKey struct {
+	Type        string `json:"type"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+}
+
+// validateCredentials — проверяет GOOGLE_CREDENTIALS до sheets.NewService, чтобы вместо
+// невнятной ошибки клиента сказать, что именно не так с ключом
+func validateCredentials(s string) error {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "{") {
+		return fmt.Errorf("ожидается содержимое JSON-ключа сервисного аккаунта, а не путь к файлу или другой текст")
+	}
+	var key serviceAccountKey
+	if err := json.Unmarshal([]byte(s), &key); err != nil {
+		return fmt.Errorf("некорректный JSON: %v", err)
+	}
+	if key.Type != "service_account" {
+		if key.Type == "" {
+			return fmt.Errorf("нет поля type (ожидается \"service_account\")")
+		}
+		return fmt.Errorf("type = %q, а нужен ключ сервисного аккаунта (\"service_account\")", key.Type)
+	}
+	var missing []string
+	if key.ClientEmail == "" {
+		missing = append(missing, "client_email")
+	}
+	if key.PrivateKey == "" {
+		missing = append(missing, "private_key")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("нет полей %s", strings.Join(missing, ", "))
+	}
+	if block, _ := pem.Decode([]byte(key.PrivateKey)); block == nil {
+		// Частая причина — переводы строк в ключе экранированы дважды (\\n) при копировании в .env
+		return fmt.Errorf("private_key не является PEM-ключом (проверьте переводы строк \\n)")
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// csvHeader — колонки CSV-выгрузки
+var csvHeader = []string{
+	"lat", "lon", "lotName", "lotDescription", "title", "link",
+	"priority", "category", "thumbUrl", "imageUrl", "count",
+}
+
+// outputHeader — csvHeader с учётом FIELD_RENAME (для CSV и XLSX)
+func outputHeader() []string {
+	header := make([]string, len(csvHeader))
+	for i, h := range csvHeader {
+		header[i] = outputName(h)
+	}
+	return header
+}
+
+// csvLocale — разделители CSV для локали: европейский Excel ждёт десятичную запятую
+// и точку с запятой между полями
+type csvLocale struct {
+	decimal rune
+	comma   rune
+	// bom — UTF-8 BOM в начале файла, иначе Excel открывает кириллицу как cp1251
+	bom bool
+}
+
+// csvLocales — поддерживаемые значения параметра locale. По умолчанию (en):
+// точка в дробях, запятая между полями, без BOM.
+var csvLocales = map[string]csvLocale{
+	"en": {'.', ',', false},
+	"ru": {',', ';', true},
+	"uk": {',', ';', true},
+	"de": {',', ';', true},
+	"fr": {',', ';', true},
+	"es": {',', ';', true},
+	"it": {',', ';', true},
+}
+
+// formatFloat — число с десятичным разделителем локали
+func (l csvLocale) formatFloat(v float64) string {
+	s := strconv.FormatFloat(v, 'f', -1, 64)
+	if l.decimal != '.' {
+		s = strings.Replace(s, ".", string(l.decimal), 1)
+	}
+	return s
+}
+
+// writeCSV — потоково отдаёт точки в CSV: строки пишутся через буфер csv.Writer
+// и сбрасываются клиенту каждые flushEvery строк, поэтому память не растёт с объёмом,
+// а загрузка начинается сразу. Ошибка посреди потока возвращается вызывающему —
+// статус к этому моменту уже отправлен, остаётся только прекратить запись.
+func writeCSV(w http.ResponseWriter, points []LotPoint, loc csvLocale, flushEvery int) error {
+	cw, flush, err := startCSV(w, loc, "points.csv")
+	if err != nil {
+		return err
+	}
+
+	if err := cw.Write(outputHeader()); err != nil {
+		return err
+	}
+	for i, p := range points {
+		record := []string{
+			loc.formatFloat(p.Lat),
+			loc.formatFloat(p.Lon),
+			p.LotName, p.LotDescription, p.Title, p.Link,
+			strconv.Itoa(p.Priority), p.Category, p.ThumbURL, p.ImageURL,
+			strconv.Itoa(max(p.Count, 1)),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+		if flushEvery > 0 && (i+1)%flushEvery == 0 {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return flush()
+}
+
+// startCSV — заголовки ответа, BOM и csv.Writer с разделителем локали; flush сбрасывает
+// накопленное клиенту
+func startCSV(w http.ResponseWriter, loc csvLocale, filename string) (*csv.Writer, func() error, error) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+	flusher, _ := w.(http.Flusher)
+	if loc.bom {
+		if _, err := w.Write([]byte("\uFEFF")); err != nil {
+			return nil, nil, err
+		}
+	}
+	cw := csv.NewWriter(w)
+	cw.Comma = loc.comma
+	flush := func() error {
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	}
+	return cw, flush, nil
+}
+
+// issuesCSVHeader — колонки выгрузки проблемных строк
+var issuesCSVHeader = []string{"row", "problem", "message", "value", "skipped"}
+
+// writeIssuesCSV — проблемные строки таблицы в CSV для редакторов: открыть рядом с таблицей
+// и пройтись по номерам строк
+func writeIssuesCSV(w http.ResponseWriter, issues []rowIssue, loc csvLocale) error {
+	cw, flush, err := startCSV(w, loc, "incomplete.csv")
+	if err != nil {
+		return err
+	}
+	if err := cw.Write(issuesCSVHeader); err != nil {
+		return err
+	}
+	for _, is := range issues {
+		record := []string{strconv.Itoa(is.Row), is.Problem, is.Message, is.Value, strconv.FormatBool(is.Skipped)}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	return flush()
+}
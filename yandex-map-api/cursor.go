@@ -0,0 +1,112 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+)
+
+// Курсорная постраничная выдача (cursor=...). Курсор помнит, на какой точке закончилась
+// страница: её ключ и позицию. Следующая страница начинается после точки с этим ключом,
+// поэтому строки, добавленные или удалённые выше по списку, не сдвигают выдачу, как offset.
+// Если самой точки уже нет, продолжаем с запомненной позиции.
+//
+// Курсор подписан HMAC (CURSOR_SECRET), чтобы клиент не мог подставить произвольную позицию.
+// Без CURSOR_SECRET ключ случайный на время работы процесса: после перезапуска курсоры
+// недействительны, и за несколькими экземплярами сервиса секрет нужно задать явно.
+
+// cursorSecret — ключ подписи курсоров
+var cursorSecret []byte
+
+// randomCursorSecret — ключ подписи на время работы процесса
+func randomCursorSecret() []byte {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// pageCursor — содержимое курсора: ключ последней отданной точки и позиция следующей
+type pageCursor struct {
+	Key string `json:"k"`
+	Pos int    `json:"p"`
+}
+
+// cursorSigLen — длина подписи в байтах (усечённый HMAC-SHA256)
+const cursorSigLen = 16
+
+// cursorKey — ключ точки для курсора: ID, а без колонки ID — хэш названия, ссылки и координат
+func cursorKey(p LotPoint) string {
+	if p.ID != "" {
+		return "id:" + p.ID
+	}
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s|%s|%g|%g", p.LotName, p.Link, p.Lat, p.Lon)
+	return fmt.Sprintf("h:%016x", h.Sum64())
+}
+
+func cursorSignature(payload string) []byte {
+	mac := hmac.New(sha256.New, cursorSecret)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)[:cursorSigLen]
+}
+
+// encodeCursor — курсор для продолжения после points[pos-1]
+func encodeCursor(points []LotPoint, pos int) string {
+	b, _ := json.Marshal(pageCursor{Key: cursorKey(points[pos-1]), Pos: pos})
+	payload := base64.RawURLEncoding.EncodeToString(b)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(cursorSignature(payload))
+}
+
+// decodeCursor — проверяет подпись и разбирает курсор
+func decodeCursor(s string) (*pageCursor, error) {
+	payload, sig, ok := strings.Cut(s, ".")
+	if !ok {
+		return nil, errors.New("неверный формат")
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, cursorSignature(payload)) {
+		return nil, errors.New("неверная подпись")
+	}
+	b, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, errors.New("неверный формат")
+	}
+	var c pageCursor
+	if err := json.Unmarshal(b, &c); err != nil || c.Pos < 1 {
+		return nil, errors.New("неверный формат")
+	}
+	return &c, nil
+}
+
+// start — с какой позиции в points продолжать: после точки с ключом курсора (ближайшей
+// к запомненной позиции, если ключ повторяется), иначе с самой позиции
+func (c *pageCursor) start(points []LotPoint) int {
+	best := -1
+	for i, p := range points {
+		if cursorKey(p) != c.Key {
+			continue
+		}
+		if best < 0 || abs(i+1-c.Pos) < abs(best+1-c.Pos) {
+			best = i
+		}
+	}
+	if best >= 0 {
+		return best + 1
+	}
+	return min(c.Pos, len(points))
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// defaultableFields — логические поля, для которых можно задать значение по умолчанию
+var defaultableFields = map[string]bool{
+	"lotName":        true,
+	"lotDescription": true,
+	"link":           true,
+	"linkText":       true,
+	"priority":       true,
+	"category":       true,
+	"subcategory":    true,
+	"region":         true,
+	"status":         true,
+}
+
+// fieldDefaults — значения, подставляемые вместо пустых ячеек (логическое поле → значение)
+type fieldDefaults map[string]string
+
+// parseDefaults — разбирает DEFAULTS: JSON-объект {"поле": "значение"}
+func parseDefaults(s string) (fieldDefaults, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	var d fieldDefaults
+	if err := json.Unmarshal([]byte(s), &d); err != nil {
+		return nil, fmt.Errorf("ожидается JSON-объект: %w", err)
+	}
+	for field, value := range d {
+		if !defaultableFields[field] {
+			return nil, fmt.Errorf("неизвестное поле %q", field)
+		}
+		if field == "priority" {
+			if _, err := strconv.ParseFloat(value, 64); err != nil {
+				return nil, fmt.Errorf("priority должен быть числом, получено %q", value)
+			}
+		}
+	}
+	return d, nil
+}
+
+// or — значение ячейки или, если она пустая, значение по умолчанию для поля
+func (d fieldDefaults) or(field, value string) string {
+	if strings.TrimSpace(value) == "" {
+		return d[field]
+	}
+	return value
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+const defaultDensityGrid = 10
+
+// densityCell — ячейка сетки плотности
+type densityCell struct {
+	Row    int  `json:"row"`
+	Col    int  `json:"col"`
+	Count  int  `json:"count"`
+	Bounds bbox `json:"bounds"`
+}
+
+type densityResponse struct {
+	BBox  bbox          `json:"bbox"`
+	Cols  int           `json:"cols"`
+	Rows  int           `json:"rows"`
+	Total int           `json:"total"`
+	Cells []densityCell `json:"cells"`
+}
+
+// densityHandler — GET /api/points/density?bbox=minLon,minLat,maxLon,maxLat&cols=&rows=
+// Делит область на cols×rows ячеек и считает точки в каждой (для слоя плотности).
+// Без bbox берётся охват всех точек. Ячейки идут построчно с юга на север, с запада на восток.
+func densityHandler(cache *pointCache, maxCells int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method != http.MethodGet {
+			http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+			return
+		}
+
+		q := r.URL.Query()
+		cols, rows := defaultDensityGrid, defaultDensityGrid
+		for _, p := range []struct {
+			name string
+			dst  *int
+		}{{"cols", &cols}, {"rows", &rows}} {
+			if v := q.Get(p.name); v != "" {
+				n, err := strconv.Atoi(v)
+				if err != nil || n <= 0 {
+					http.Error(w, "Некорректный параметр "+p.name, http.StatusBadRequest)
+					return
+				}
+				*p.dst = n
+			}
+		}
+		if cols > maxCells || rows > maxCells || cols*rows > maxCells {
+			http.Error(w, "Слишком большая сетка: cols×rows не больше "+strconv.Itoa(maxCells), http.StatusBadRequest)
+			return
+		}
+
+		var area bbox
+		hasArea := false
+		if v := q.Get("bbox"); v != "" {
+			b, err := parseBBox(v)
+			if err != nil {
+				http.Error(w, "Некорректный параметр bbox: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			area, hasArea = b, true
+		}
+
+		data, err := cache.get(r.Context())
+		if err != nil {
+			writeLoadError(w, err)
+			return
+		}
+		if !hasArea {
+			area, hasArea = boundsOf(data.Points)
+		}
+
+		resp := densityResponse{BBox: area, Cols: cols, Rows: rows, Cells: []densityCell{}}
+		if hasArea {
+			resp.Cells, resp.Total = densityGrid(data.Points, area, cols, rows)
+		}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			log.Printf("❌ Ошибка отправки JSON: %v", err)
+		}
+	}
+}
+
+// densityGrid — считает точки по ячейкам сетки; точки на северной и восточной
+// границах области попадают в крайние ячейки
+func densityGrid(points []LotPoint, area bbox, cols, rows int) ([]densityCell, int) {
+	cellW := (area[2] - area[0]) / float64(cols)
+	cellH := (area[3] - area[1]) / float64(rows)
+
+	cells := make([]densityCell, cols*rows)
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
+			minLon := area[0] + float64(col)*cellW
+			minLat := area[1] + float64(row)*cellH
+			cells[row*cols+col] = densityCell{
+				Row: row, Col: col,
+				Bounds: bbox{minLon, minLat, minLon + cellW, minLat + cellH},
+			}
+		}
+	}
+
+	total := 0
+	for _, p := range points {
+		if !area.contains(p.Lat, p.Lon) {
+			continue
+		}
+		col, row := cols-1, rows-1
+		if cellW > 0 {
+			col = min(int((p.Lon-area[0])/cellW), cols-1)
+		}
+		if cellH > 0 {
+			row = min(int((p.Lat-area[1])/cellH), rows-1)
+		}
+		cells[row*cols+col].Count++
+		total++
+	}
+	return cells, total
+}
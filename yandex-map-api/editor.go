@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"google.golang.org/api/drive/v3"
+)
+
+// editorCacheTTL — как часто спрашивать Drive о последнем редакторе
+const editorCacheTTL = time.Minute
+
+// lastEditor — кто и когда последним изменил таблицу (по данным Drive).
+// Почта не отдаётся: /health/detail доступен без авторизации.
+type lastEditor struct {
+	Name       string    `json:"name"`
+	ModifiedAt time.Time `json:"modifiedAt"`
+}
+
+// editorSource — последний редактор таблицы через Drive API (LAST_EDITOR, нужна область drive.metadata.readonly)
+type editorSource struct {
+	service *drive.Service
+	fileID  string
+
+	mu        sync.Mutex
+	cached    *lastEditor
+	fetchedAt time.Time
+	warned    bool
+}
+
+// get — последний редактор или nil, если Drive не дал такой информации (нет доступа и т.п.)
+func (e *editorSource) get(ctx context.Context) *lastEditor {
+	if e == nil {
+		return nil
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if time.Since(e.fetchedAt) < editorCacheTTL {
+		return e.cached
+	}
+	e.fetchedAt = time.Now()
+
+	f, err := e.service.Files.Get(e.fileID).
+		Fields("modifiedTime", "lastModifyingUser(displayName)").
+		SupportsAllDrives(true).Context(ctx).Do()
+	if err != nil {
+		if !e.warned {
+			log.Printf("⚠️ Не удалось узнать последнего редактора через Drive (проверьте доступ и область drive.metadata.readonly): %v", err)
+			e.warned = true
+		}
+		e.cached = nil
+		return nil
+	}
+	e.warned = false
+
+	editor := &lastEditor{}
+	if f.LastModifyingUser != nil {
+		editor.Name = f.LastModifyingUser.DisplayName
+	}
+	if t, err := time.Parse(time.RFC3339, f.ModifiedTime); err == nil {
+		editor.ModifiedAt = t
+	}
+	if editor.Name == "" && editor.ModifiedAt.IsZero() {
+		editor = nil
+	}
+	e.cached = editor
+	return editor
+}
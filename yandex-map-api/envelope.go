@@ -0,0 +1,81 @@
+package main
+
+import "strings"
+
+// Преобразования, после которых данные в ответе отличаются от таблицы
+const (
+	transformSnap        = "snap"        // координаты перенесены в центры ячеек сетки (SNAP_GRID)
+	transformApproximate = "approximate" // у части точек координаты — центр региона (APPROXIMATE_PLACEMENT)
+	transformCoordOrder  = "coordOrder"  // перепутанные широта и долгота исправлены (AUTO_FIX_COORD_ORDER)
+	transformDefaults    = "defaults"    // пустые ячейки заполнены значениями по умолчанию (DEFAULTS)
+	transformTruncate    = "truncate"    // описание в title обрезано (TITLE_TEMPLATE, TITLE_DESC_MAX)
+	transformRedact      = "redact"      // поля, недоступные по ключу, очищены (ACCESS_TIERS)
+	transformDedup       = "dedup"       // точки с одинаковыми координатами объединены (dedup=true)
+	transformThin        = "thin"        // близкие точки прорежены (thin=<метры>)
+)
+
+// pointsEnvelope — ответ /api/points с envelope=true: точки (или группы) и сведения о них
+type pointsEnvelope struct {
+	Data interface{}  `json:"data"`
+	Meta envelopeMeta `json:"meta"`
+}
+
+type envelopeMeta struct {
+	Total int `json:"total"` // точек до разбиения на страницы
+	Count int `json:"count"` // точек в этом ответе
+	// NextCursor — курсор следующей страницы (cursor=...), нет — это последняя
+	NextCursor string `json:"nextCursor,omitempty"`
+	// Transforms — что сделано с данными по пути из таблицы, в порядке применения;
+	// пустой массив — данные как в таблице
+	Transforms []string `json:"transforms"`
+}
+
+// transforms — преобразования, которые загрузчик применяет ко всем точкам при чтении таблицы.
+// Шаги PIPELINE_FILE перечисляются под своими именами (fuzz, round, trim...), каждый один раз.
+func (l *sheetLoader) transforms() []string {
+	var list []string
+	if l.defaults != nil {
+		list = append(list, transformDefaults)
+	}
+	if l.autoFixCoordOrder {
+		list = append(list, transformCoordOrder)
+	}
+	if l.approximatePlacement {
+		list = append(list, transformApproximate)
+	}
+	if l.snapGrid > 0 {
+		list = append(list, transformSnap)
+	}
+	if l.title != nil && l.title.descMax > 0 && strings.Contains(l.title.tmpl, "{desc}") {
+		list = append(list, transformTruncate)
+	}
+	for _, name := range l.pipeline.names() {
+		list = appendTransform(list, name)
+	}
+	return list
+}
+
+// requestTransforms — преобразования ответа: загрузчика плюс зависящие от ключа и параметров запроса
+func requestTransforms(base []string, tier *accessTier, filters pointFilters) []string {
+	list := append([]string{}, base...)
+	if tier != nil && tier.fields != nil {
+		list = appendTransform(list, transformRedact)
+	}
+	if filters.dedup {
+		list = appendTransform(list, transformDedup)
+	}
+	if filters.thinMeters > 0 {
+		list = appendTransform(list, transformThin)
+	}
+	return list
+}
+
+// appendTransform — добавляет преобразование, если его ещё нет в списке
+func appendTransform(list []string, name string) []string {
+	for _, t := range list {
+		if t == name {
+			return list
+		}
+	}
+	return append(list, name)
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+// Коды ошибок в JSON-ответах
+const (
+	errCodeRateLimited      = "rate_limited"
+	errCodeOverloaded       = "overloaded"
+	errCodePermissionDenied = "permission_denied"
+	errCodeSheetNotFound    = "sheet_not_found"
+	errCodeBadRange         = "bad_range"
+	errCodeUpstream         = "upstream_error"
+	errCodeUnauthorized     = "unauthorized"
+)
+
+// sheetsErrorClass — как сообщить клиенту об ошибке Google Sheets с данным кодом
+type sheetsErrorClass struct {
+	code    string
+	message string
+}
+
+var sheetsErrorClasses = map[int]sheetsErrorClass{
+	http.StatusBadRequest:      {errCodeBadRange, "Таблица отклонила запрос: проверьте имя листа и диапазон"},
+	http.StatusForbidden:       {errCodePermissionDenied, "Нет доступа к таблице: откройте её сервисному аккаунту"},
+	http.StatusNotFound:        {errCodeSheetNotFound, "Таблица не найдена: проверьте GOOGLE_SHEET_ID"},
+	http.StatusTooManyRequests: {errCodeRateLimited, "Квота Google Sheets исчерпана, повторите запрос позже"},
+}
+
+// sheetsErrorStatuses — HTTP-статус ответа по коду ошибки Google Sheets;
+// переопределяется через SHEETS_ERROR_STATUS. Прочие коды — 502.
+var sheetsErrorStatuses = map[int]int{
+	http.StatusBadRequest:      http.StatusInternalServerError,
+	http.StatusForbidden:       http.StatusBadGateway,
+	http.StatusNotFound:        http.StatusInternalServerError,
+	http.StatusTooManyRequests: http.StatusServiceUnavailable,
+}
+
+// parseSheetsErrorStatuses — разбирает SHEETS_ERROR_STATUS: "403=500,429=429"
+func parseSheetsErrorStatuses(s string) error {
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		from, to, ok := strings.Cut(pair, "=")
+		src, err1 := strconv.Atoi(strings.TrimSpace(from))
+		dst, err2 := strconv.Atoi(strings.TrimSpace(to))
+		if !ok || err1 != nil || err2 != nil || src < 400 || src > 599 || dst < 400 || dst > 599 {
+			return fmt.Errorf("ожидается код=статус, получено %q", pair)
+		}
+		sheetsErrorStatuses[src] = dst
+	}
+	return nil
+}
+
+// classifySheetsError — HTTP-статус для ошибки обращения к Google Sheets
+// (0 — это не ошибка Sheets). Исчерпание квоты на нашей стороне считается как 429.
+func classifySheetsError(err error) int {
+	code, ok := sheetsErrorCode(err)
+	if !ok {
+		return 0
+	}
+	if status, ok := sheetsErrorStatuses[code]; ok {
+		return status
+	}
+	return http.StatusBadGateway
+}
+
+// sheetsErrorCode — код ответа Google Sheets из цепочки ошибок
+func sheetsErrorCode(err error) (int, bool) {
+	var qerr *quotaError
+	if errors.As(err, &qerr) {
+		return http.StatusTooManyRequests, true
+	}
+	var gerr *googleapi.Error
+	if errors.As(err, &gerr) {
+		return gerr.Code, true
+	}
+	return 0, false
+}
+
+// apiError — стандартное тело JSON-ошибки: {"error":{"code":...,"message":...}}
+type apiError struct {
+	Error apiErrorBody `json:"error"`
+}
+
+type apiErrorBody struct {
+	Code              string `json:"code"`
+	Message           string `json:"message,omitempty"`
+	RetryAfterSeconds int    `json:"retryAfterSeconds,omitempty"`
+}
+
+// writeAPIError — отправляет ошибку в стандартной JSON-схеме.
+// Если retryAfter > 0, дублирует его в заголовке Retry-After (целые секунды, с округлением вверх).
+func writeAPIError(w http.ResponseWriter, status int, code, message string, retryAfter time.Duration) {
+	body := apiErrorBody{Code: code, Message: message}
+	if retryAfter > 0 {
+		body.RetryAfterSeconds = int(math.Ceil(retryAfter.Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(body.RetryAfterSeconds))
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(apiError{Error: body})
+}
+
+// loadError — ошибка загрузки данных с HTTP-статусом и сообщением для клиента
+type loadError struct {
+	status  int
+	message string
+	err     error
+}
+
+func (e *loadError) Error() string {
+	if e.err != nil {
+		return e.message + ": " + e.err.Error()
+	}
+	return e.message
+}
+
+func (e *loadError) Unwrap() error { return e.err }
+
+// writeLoadError — отправляет клиенту ошибку загрузки.
+// Ошибки Google Sheets отдаются в JSON-схеме со статусом из classifySheetsError;
+// при исчерпанной квоте — с retryAfterSeconds, чтобы клиент мог выждать.
+func writeLoadError(w http.ResponseWriter, err error) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		http.Error(w, "Превышено время ожидания ответа таблицы", http.StatusGatewayTimeout)
+		return
+	}
+	if status := classifySheetsError(err); status != 0 {
+		code, _ := sheetsErrorCode(err)
+		class, ok := sheetsErrorClasses[code]
+		if !ok {
+			class = sheetsErrorClass{errCodeUpstream, "Ошибка Google Sheets"}
+		}
+		// Неразобранный диапазон — обычно переименованный лист; loadError называет его
+		if isSheetNotFound(err) {
+			class.code = errCodeSheetNotFound
+			var le *loadError
+			if errors.As(err, &le) {
+				class.message = le.message
+			}
+		}
+		retryAfter, _ := quotaRetryAfter(err)
+		writeAPIError(w, status, class.code, class.message, retryAfter)
+		return
+	}
+	if le, ok := err.(*loadError); ok {
+		http.Error(w, le.message, le.status)
+		return
+	}
+	http.Error(w, "Ошибка чтения данных", http.StatusInternalServerError)
+}
+
+// quotaRetryAfter — распознаёт исчерпание квоты Sheets и возвращает, через сколько повторять.
+// Если срок неизвестен, отдаём минимальную паузу, чтобы клиент не повторял сразу же.
+func quotaRetryAfter(err error) (time.Duration, bool) {
+	var qerr *quotaError
+	if errors.As(err, &qerr) {
+		return atLeastSecond(time.Until(qerr.until)), true
+	}
+	if !isQuotaExceeded(err) {
+		return 0, false
+	}
+	if d, ok := retryAfterFromError(err); ok {
+		return atLeastSecond(d), true
+	}
+	return atLeastSecond(time.Until(sheetsNextAvailable())), true
+}
+
+func atLeastSecond(d time.Duration) time.Duration {
+	if d < time.Second {
+		return time.Second
+	}
+	return d
+}
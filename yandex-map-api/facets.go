@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// facetValue — одно значение колонки и число точек с ним
+type facetValue struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// facetsResponse — ответ /api/facets/{field}
+type facetsResponse struct {
+	Field  string       `json:"field"`
+	Values []facetValue `json:"values"`
+	// Missing — сколько точек без значения
+	Missing int `json:"missing,omitempty"`
+}
+
+// computeFacets — различные значения каждого поля из groupableFields по убыванию частоты
+// (при равенстве — по алфавиту). Считается один раз на загрузку и хранится в dataset.
+func computeFacets(points []LotPoint) map[string]facetsResponse {
+	facets := make(map[string]facetsResponse, len(groupableFields))
+	for field, valueOf := range groupableFields {
+		counts := make(map[string]int)
+		resp := facetsResponse{Field: field, Values: []facetValue{}}
+		for _, p := range points {
+			if v := valueOf(p); v != "" {
+				counts[v]++
+			} else {
+				resp.Missing++
+			}
+		}
+		for v, n := range counts {
+			resp.Values = append(resp.Values, facetValue{v, n})
+		}
+		sort.Slice(resp.Values, func(i, j int) bool {
+			if resp.Values[i].Count != resp.Values[j].Count {
+				return resp.Values[i].Count > resp.Values[j].Count
+			}
+			return resp.Values[i].Value < resp.Values[j].Value
+		})
+		facets[field] = resp
+	}
+	return facets
+}
+
+// facetsHandler — GET /api/facets/{field}: различные значения колонки для фильтров
+func facetsHandler(cache *pointCache, access *accessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method != http.MethodGet {
+			http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+			return
+		}
+
+		field := strings.TrimPrefix(r.URL.Path, "/api/facets/")
+		if _, ok := groupableFields[field]; !ok {
+			http.Error(w, "Неизвестное поле (допустимо: "+strings.Join(groupableFieldNames(), ", ")+")", http.StatusBadRequest)
+			return
+		}
+
+		tier, ok := access.tierFor(r)
+		if !ok {
+			writeUnknownKey(w)
+			return
+		}
+		if !tier.allows(field) {
+			http.Error(w, "Поле "+field+" недоступно для этого ключа", http.StatusForbidden)
+			return
+		}
+
+		data, err := cache.get(r.Context())
+		if err != nil {
+			writeLoadError(w, err)
+			return
+		}
+		if err := json.NewEncoder(w).Encode(data.Facets[field]); err != nil {
+			log.Printf("❌ Ошибка отправки JSON: %v", err)
+		}
+	}
+}
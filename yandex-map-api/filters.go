@@ -0,0 +1,274 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// pointFilters — параметры /api/points, от которых зависит, какие точки попадут в ответ
+type pointFilters struct {
+	ids            []string
+	search         *pointSearch
+	frame          *time.Time
+	dedup          bool
+	dedupMeters    float64
+	thinMeters     float64
+	sortByPriority bool
+	limit, offset  int
+	cursor         *pageCursor
+}
+
+// parsePointFilters — разбирает параметры отбора точек; limit не больше maxPoints (0 — без ограничения)
+func parsePointFilters(q url.Values, maxPoints int) (pointFilters, error) {
+	f := pointFilters{limit: maxPoints, sortByPriority: true}
+
+	// Необязательное прореживание: thin=<метры>
+	if v := q.Get("thin"); v != "" {
+		m, err := strconv.ParseFloat(v, 64)
+		if err != nil || m <= 0 {
+			return f, fmt.Errorf("Некорректный параметр thin")
+		}
+		f.thinMeters = m
+	}
+
+	// Необязательно: схлопнуть точки с одинаковыми координатами (dedup=true);
+	// dedupTolerance=<метры> — считать одинаковыми и координаты, различающиеся меньше чем на столько
+	// (по умолчанию DEDUP_TOLERANCE_METERS)
+	f.dedup = q.Get("dedup") == "true"
+	f.dedupMeters = dedupToleranceMeters
+	if v := q.Get("dedupTolerance"); v != "" {
+		m, err := strconv.ParseFloat(v, 64)
+		if err != nil || m < 0 || m > maxDedupToleranceMeters {
+			return f, fmt.Errorf("Некорректный параметр dedupTolerance (0–%d м)", maxDedupToleranceMeters)
+		}
+		f.dedupMeters = m
+	}
+
+	// Необязательно: только точки с перечисленными ID (ids=a,b,c) в порядке перечисления
+	if v := q.Get("ids"); v != "" {
+		ids, err := parseIDList(v)
+		if err != nil {
+			return f, fmt.Errorf("Некорректный параметр ids: %v", err)
+		}
+		f.ids = ids
+	}
+
+	// Необязательно: поиск по тексту q (все слова, без учёта регистра); где искать — searchFields:
+	// поля через запятую или all, по умолчанию название и описание
+	search, err := parseSearch(q.Get("q"), q.Get("searchFields"))
+	if err != nil {
+		return f, fmt.Errorf("Некорректный поиск: %v", err)
+	}
+	f.search = search
+
+	// Необязательно: кадр анимации — точки, добавленные не позже frame (по колонке Created_at)
+	if v := q.Get("frame"); v != "" {
+		t, err := parseFrame(v)
+		if err != nil {
+			return f, fmt.Errorf("Некорректный параметр frame: %v", err)
+		}
+		f.frame = &t
+	}
+
+	// Порядок вывода: по умолчанию по приоритету (важные — последними, поверх остальных);
+	// с ids порядок задаёт клиент
+	switch q.Get("sort") {
+	case "", "priority":
+		f.sortByPriority = f.ids == nil
+	case "none":
+		f.sortByPriority = false
+	default:
+		return f, fmt.Errorf("Некорректный параметр sort (допустимо: priority, none)")
+	}
+
+	// Постраничная выдача: limit/offset, limit не больше MAX_POINTS
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return f, fmt.Errorf("Некорректный параметр limit")
+		}
+		if maxPoints == 0 || n < maxPoints {
+			f.limit = n
+		}
+	}
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return f, fmt.Errorf("Некорректный параметр offset")
+		}
+		f.offset = n
+	}
+	// Курсор вместо offset: cursor=<nextCursor из предыдущего ответа>
+	if v := q.Get("cursor"); v != "" {
+		if q.Get("offset") != "" {
+			return f, fmt.Errorf("Параметры cursor и offset несовместимы")
+		}
+		c, err := decodeCursor(v)
+		if err != nil {
+			return f, fmt.Errorf("Некорректный параметр cursor: %v", err)
+		}
+		f.cursor = c
+	}
+	return f, nil
+}
+
+// filterResult — итог отбора: страница точек и сведения для заголовков ответа
+type filterResult struct {
+	points     []LotPoint
+	total      int      // точек до разбиения на страницы
+	nextOffset int      // смещение следующей страницы (0 — это последняя)
+	nextCursor string   // курсор следующей страницы ("" — это последняя)
+	missingIDs []string // ID из ids, которых нет в таблице
+}
+
+// apply — отбирает точки по этапам: ids → q → frame → dedup → thin → сортировка → страница.
+// stage, если задан, вызывается после каждого этапа с числом оставшихся точек.
+// points изменяется на месте (должна быть копией).
+func (f pointFilters) apply(points []LotPoint, stage func(name string, remaining int)) filterResult {
+	if stage == nil {
+		stage = func(string, int) {}
+	}
+	var res filterResult
+
+	if f.ids != nil {
+		points, res.missingIDs = selectByIDs(points, f.ids)
+		stage("ids", len(points))
+	}
+
+	if f.search != nil {
+		points = f.search.filter(points)
+		stage("q", len(points))
+	}
+
+	if f.frame != nil {
+		kept := points[:0]
+		for _, p := range points {
+			if p.CreatedAt != nil && !p.CreatedAt.After(*f.frame) {
+				kept = append(kept, p)
+			}
+		}
+		points = kept
+		stage("frame", len(points))
+	}
+
+	if f.dedup {
+		points = dedupPoints(points, f.dedupMeters)
+		stage("dedup", len(points))
+	}
+
+	if f.thinMeters > 0 {
+		before := len(points)
+		points = thinPoints(points, f.thinMeters)
+		log.Printf("ℹ️ Прореживание %.0f м: %d → %d точек", f.thinMeters, before, len(points))
+		stage("thin", len(points))
+	}
+
+	if f.sortByPriority {
+		// Стабильная сортировка: при равном приоритете сохраняется порядок строк таблицы
+		sort.SliceStable(points, func(i, j int) bool {
+			return points[i].Priority < points[j].Priority
+		})
+	}
+
+	// Обрезаем страницу и запоминаем, есть ли продолжение
+	res.total = len(points)
+	if f.offset > 0 || f.cursor != nil || (f.limit > 0 && res.total > f.limit) {
+		offset := min(f.offset, res.total)
+		if f.cursor != nil {
+			offset = f.cursor.start(points)
+		}
+		end := res.total
+		if f.limit > 0 && offset+f.limit < res.total {
+			end = offset + f.limit
+		}
+		if end < res.total {
+			res.nextOffset = end
+			res.nextCursor = encodeCursor(points, end)
+		}
+		points = points[offset:end]
+		stage("page", len(points))
+	}
+
+	res.points = points
+	return res
+}
+
+// explainStage — сколько точек осталось после этапа отбора и сколько он убрал
+type explainStage struct {
+	Stage     string `json:"stage"`
+	Remaining int    `json:"remaining"`
+	Removed   int    `json:"removed"`
+}
+
+type explainResponse struct {
+	Total      int            `json:"total"`
+	Stages     []explainStage `json:"stages"`
+	Returned   int            `json:"returned"`
+	MissingIDs []string       `json:"missingIds,omitempty"`
+}
+
+// explainHandler — GET /api/points/explain: те же параметры отбора, что у /api/points,
+// но вместо точек — сколько их убрал каждый этап. Помогает разобраться, почему фильтр
+// ничего не возвращает. Отбор идёт по полям, доступным ключу, — как в /api/points,
+// иначе по числам можно было бы угадать скрытые значения.
+func explainHandler(cache *pointCache, access *accessControl, maxPoints int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method != http.MethodGet {
+			http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+			return
+		}
+
+		tier, ok := access.tierFor(r)
+		if !ok {
+			writeUnknownKey(w)
+			return
+		}
+
+		filters, err := parsePointFilters(r.URL.Query(), maxPoints)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		data, err := cache.get(r.Context())
+		if err != nil {
+			writeLoadError(w, err)
+			return
+		}
+
+		if filters.frame != nil && !data.HasCreatedAt {
+			http.Error(w, "Параметр frame требует колонку Created_at в таблице", http.StatusBadRequest)
+			return
+		}
+		// Без доступа к createdAt дата у всех точек очищена — кадр был бы молча пустым
+		if filters.frame != nil && !tier.allows("createdAt") {
+			http.Error(w, "Параметр frame недоступен для этого ключа: нет доступа к полю createdAt", http.StatusBadRequest)
+			return
+		}
+
+		points := make([]LotPoint, len(data.Points))
+		copy(points, data.Points)
+		tier.project(points)
+
+		resp := explainResponse{Total: len(points), Stages: []explainStage{}}
+		prev := len(points)
+		res := filters.apply(points, func(name string, remaining int) {
+			resp.Stages = append(resp.Stages, explainStage{Stage: name, Remaining: remaining, Removed: prev - remaining})
+			prev = remaining
+		})
+		resp.Returned = len(res.points)
+		resp.MissingIDs = res.missingIDs
+
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			log.Printf("❌ Ошибка отправки JSON: %v", err)
+		}
+	}
+}
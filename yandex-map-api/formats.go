@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// writeMsgpack — отдаёт точки в MessagePack. Имена полей берутся из json-тегов,
+// поэтому набор полей совпадает с JSON-ответом.
+func writeMsgpack(w http.ResponseWriter, v interface{}) error {
+	w.Header().Set("Content-Type", "application/msgpack")
+	enc := msgpack.NewEncoder(w)
+	enc.SetCustomStructTag("json")
+	return enc.Encode(v)
+}
+
+// writeBinary — отдаёт координаты плоским массивом little-endian float32: lat, lon, lat, lon, ...
+// (в браузере — сразу Float32Array); число точек — в заголовке X-Point-Count.
+func writeBinary(w http.ResponseWriter, points []LotPoint) error {
+	buf := make([]byte, 8*len(points))
+	for i, p := range points {
+		binary.LittleEndian.PutUint32(buf[8*i:], math.Float32bits(float32(p.Lat)))
+		binary.LittleEndian.PutUint32(buf[8*i+4:], math.Float32bits(float32(p.Lon)))
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Length", strconv.Itoa(len(buf)))
+	w.Header().Set("X-Point-Count", strconv.Itoa(len(points)))
+	_, err := w.Write(buf)
+	return err
+}
+
+// knownFormats — значения параметра format в порядке упоминания в сообщениях об ошибках
+var knownFormats = []string{"json", "msgpack", "csv", "binary", "geojson", "xlsx", "gmaps"}
+
+// parseEnabledFormats — разбирает ENABLED_FORMATS ("json,csv"); пусто — разрешены все
+func parseEnabledFormats(s string) (map[string]bool, error) {
+	enabled := make(map[string]bool)
+	if strings.TrimSpace(s) == "" {
+		for _, f := range knownFormats {
+			enabled[f] = true
+		}
+		return enabled, nil
+	}
+	for _, f := range strings.Split(s, ",") {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if f == "" {
+			continue
+		}
+		if !slices.Contains(knownFormats, f) {
+			return nil, fmt.Errorf("неизвестный формат %q (известны: %s)", f, strings.Join(knownFormats, ", "))
+		}
+		enabled[f] = true
+	}
+	if len(enabled) == 0 {
+		return nil, fmt.Errorf("не разрешено ни одного формата")
+	}
+	return enabled, nil
+}
+
+// formatList — разрешённые форматы через запятую, для сообщений клиенту
+func formatList(enabled map[string]bool) string {
+	var list []string
+	for _, f := range knownFormats {
+		if enabled[f] {
+			list = append(list, f)
+		}
+	}
+	return strings.Join(list, ", ")
+}
+
+// Параметры /api/points, которые поддерживаются не всеми форматами
+const (
+	optGroupBy     = "groupBy"
+	optEnvelope    = "envelope"
+	optCallback    = "callback"
+	optMaxFeatures = "maxFeatures"
+	optFields      = "fields"
+)
+
+// formatOptions — какие из этих параметров принимает каждый формат:
+//
+//	format   groupBy envelope callback maxFeatures fields
+//	json     да      да       да       —           да
+//	msgpack  да      да       —        —           да
+//	csv      —       —        —        —           да (колонки остаются, значения пустые)
+//	xlsx     —       —        —        —           да (так же)
+//	geojson  —       —        —        да          да
+//	gmaps    —       —        —        —           да
+//	binary   —       —        —        —           — (только координаты)
+//
+// Неподдерживаемое сочетание — 400 с объяснением, а не молчаливое игнорирование.
+// Параметры отбора (ids, q, thin, limit...) от формата не зависят; locale влияет только на CSV
+// и остальными форматами игнорируется.
+var formatOptions = map[string][]string{
+	"json":    {optGroupBy, optEnvelope, optCallback, optFields},
+	"msgpack": {optGroupBy, optEnvelope, optFields},
+	"csv":     {optFields},
+	"xlsx":    {optFields},
+	"geojson": {optMaxFeatures, optFields},
+	"gmaps":   {optFields},
+	"binary":  {},
+}
+
+// checkFormatOptions — все заданные параметры (present) поддерживаются форматом
+func checkFormatOptions(format string, present []string) error {
+	for _, opt := range present {
+		if slices.Contains(formatOptions[format], opt) {
+			continue
+		}
+		var supported []string
+		for _, f := range knownFormats {
+			if slices.Contains(formatOptions[f], opt) {
+				supported = append(supported, "format="+f)
+			}
+		}
+		return fmt.Errorf("%s не поддерживается для format=%s (только для %s)", opt, format, strings.Join(supported, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// samplePoints — точки с полями всех видов: пустые omitempty, указатели, время, карта
+func samplePoints() []LotPoint {
+	price := 1250000.5
+	// MessagePack декодирует время в местном поясе — в нём же и задаём, чтобы строки совпали
+	created := time.Date(2024, 3, 15, 10, 30, 0, 0, time.Local)
+	return []LotPoint{
+		{ID: "1", Lat: 55.830431, Lon: 49.066143, LotName: "Лот 1", LotDescription: "Описание",
+			Link: "https://example.com/1", Priority: 3, Category: "земля", Price: &price, Currency: "RUB",
+			Weight: 2.5, CreatedAt: &created, Extras: map[string]string{"Площадь": "12 га"}},
+		{Lat: -33.9, Lon: 151.2, LotName: "Без ссылки", Priority: 0},
+	}
+}
+
+// msgpackAsJSON — ответ writeMsgpack, декодированный в карты и приведённый к JSON-типам
+// (целые и float — числа, время — строка RFC 3339), чтобы сравнить с JSON-ответом
+func msgpackAsJSON(t *testing.T, v interface{}) interface{} {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	if err := writeMsgpack(rec, v); err != nil {
+		t.Fatalf("writeMsgpack: %v", err)
+	}
+	var decoded interface{}
+	dec := msgpack.NewDecoder(rec.Body)
+	dec.SetMapDecoder(func(d *msgpack.Decoder) (interface{}, error) { return d.DecodeUntypedMap() })
+	if err := dec.Decode(&decoded); err != nil {
+		t.Fatalf("декодирование MessagePack: %v", err)
+	}
+	return jsonRoundTrip(t, decoded)
+}
+
+func jsonRoundTrip(t *testing.T, v interface{}) interface{} {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	var out interface{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+	return out
+}
+
+func TestMsgpackMatchesJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		renames map[string]string
+		want    []string // ключи первой точки, которые обязаны быть в ответе
+	}{
+		{"теги json", nil, []string{"id", "lotName", "price", "createdAt", "extras"}},
+		{"FIELD_RENAME", map[string]string{"lotName": "name", "link": "url"}, []string{"name", "url", "price"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fieldRenames = tt.renames
+			defer func() { fieldRenames = nil }()
+
+			points := samplePoints()
+			got := msgpackAsJSON(t, points)
+			want := jsonRoundTrip(t, points)
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("MessagePack и JSON расходятся:\n msgpack: %v\n json:    %v", got, want)
+			}
+			first := got.([]interface{})[0].(map[string]interface{})
+			for _, key := range tt.want {
+				if _, ok := first[key]; !ok {
+					t.Errorf("нет ключа %q: %v", key, first)
+				}
+			}
+			for from := range tt.renames {
+				if _, ok := first[from]; ok {
+					t.Errorf("ключ %q остался под старым именем", from)
+				}
+			}
+			// omitempty соблюдается: у второй точки нет id, price и createdAt
+			second := got.([]interface{})[1].(map[string]interface{})
+			for _, key := range []string{"id", "price", "createdAt"} {
+				if _, ok := second[key]; ok {
+					t.Errorf("пустое поле %q попало в ответ", key)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteBinary(t *testing.T) {
+	points := samplePoints()
+	points = append(points, LotPoint{Lat: 90, Lon: -180}, LotPoint{Lat: 0.000123, Lon: 179.999})
+
+	rec := httptest.NewRecorder()
+	if err := writeBinary(rec, points); err != nil {
+		t.Fatalf("writeBinary: %v", err)
+	}
+	if got := rec.Header().Get("X-Point-Count"); got != strconv.Itoa(len(points)) {
+		t.Fatalf("X-Point-Count = %q, ожидалось %d", got, len(points))
+	}
+	if got := rec.Header().Get("Content-Length"); got != strconv.Itoa(8*len(points)) {
+		t.Errorf("Content-Length = %q, ожидалось %d", got, 8*len(points))
+	}
+
+	coords := make([]float32, 2*len(points))
+	if err := binary.Read(bytes.NewReader(rec.Body.Bytes()), binary.LittleEndian, coords); err != nil {
+		t.Fatalf("чтение float32: %v", err)
+	}
+	for i, p := range points {
+		if coords[2*i] != float32(p.Lat) || coords[2*i+1] != float32(p.Lon) {
+			t.Errorf("точка %d: %v, %v; ожидалось %v, %v", i, coords[2*i], coords[2*i+1], float32(p.Lat), float32(p.Lon))
+		}
+	}
+}
+
+func TestCheckFormatOptions(t *testing.T) {
+	// Ожидаемая матрица — копия таблицы из комментария к formatOptions
+	accepted := map[string]map[string]bool{
+		"json":    {optGroupBy: true, optEnvelope: true, optCallback: true, optFields: true},
+		"msgpack": {optGroupBy: true, optEnvelope: true, optFields: true},
+		"csv":     {optFields: true},
+		"xlsx":    {optFields: true},
+		"geojson": {optMaxFeatures: true, optFields: true},
+		"gmaps":   {optFields: true},
+		"binary":  {},
+	}
+	options := []string{optGroupBy, optEnvelope, optCallback, optMaxFeatures, optFields}
+
+	for _, format := range knownFormats {
+		want, ok := accepted[format]
+		if !ok {
+			t.Errorf("формат %s не описан в тесте", format)
+			continue
+		}
+		if err := checkFormatOptions(format, nil); err != nil {
+			t.Errorf("format=%s без параметров: %v", format, err)
+		}
+		for _, opt := range options {
+			err := checkFormatOptions(format, []string{opt})
+			switch {
+			case want[opt] && err != nil:
+				t.Errorf("format=%s&%s должно приниматься: %v", format, opt, err)
+			case !want[opt] && err == nil:
+				t.Errorf("format=%s&%s должно отклоняться", format, opt)
+			case !want[opt] && !strings.Contains(err.Error(), opt):
+				t.Errorf("format=%s&%s: в ошибке не назван параметр: %v", format, opt, err)
+			}
+		}
+	}
+}
+
+func TestCheckFormatOptionsCombined(t *testing.T) {
+	tests := []struct {
+		format  string
+		present []string
+		wantErr string
+	}{
+		{"json", []string{optGroupBy, optEnvelope, optFields}, ""},
+		{"geojson", []string{optMaxFeatures, optFields}, ""},
+		{"geojson", []string{optFields, optGroupBy}, "groupBy"},
+		{"csv", []string{optFields, optEnvelope}, "envelope"},
+		{"binary", []string{optFields}, "format=json"},
+		{"msgpack", []string{optCallback}, "только для format=json"},
+	}
+	for _, tt := range tests {
+		err := checkFormatOptions(tt.format, tt.present)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s %v: %v", tt.format, tt.present, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s %v: ошибка %v, ожидалось упоминание %q", tt.format, tt.present, err, tt.wantErr)
+		}
+	}
+}
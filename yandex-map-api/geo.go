@@ -0,0 +1,168 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	earthRadiusMeters = 6371000.0
+	// metersPerDegreeLat — длина одного градуса широты (приближённо)
+	metersPerDegreeLat = 111320.0
+)
+
+// haversineMeters — расстояние между двумя точками по поверхности Земли в метрах
+func haversineMeters(lat1, lon1, lat2, lon2 float64) float64 {
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLon := (lon2 - lon1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+type gridCell struct {
+	x, y int
+}
+
+// thinPoints — жадное прореживание: точка отбрасывается, если ближе minDist метров
+// к уже оставленной. Точки обходятся в исходном порядке, поэтому результат детерминирован.
+// Оставленные точки раскладываются по сетке с шагом minDist, чтобы не сравнивать всех со всеми.
+func thinPoints(points []LotPoint, minDist float64) []LotPoint {
+	if minDist <= 0 || len(points) < 2 {
+		return points
+	}
+
+	cellDeg := minDist / metersPerDegreeLat
+	cellOf := func(lat, lon float64) gridCell {
+		return gridCell{int(math.Floor(lon / cellDeg)), int(math.Floor(lat / cellDeg))}
+	}
+
+	grid := make(map[gridCell][]int)
+	kept := make([]LotPoint, 0, len(points))
+
+	for _, p := range points {
+		c := cellOf(p.Lat, p.Lon)
+		// Градус долготы короче градуса широты, поэтому по долготе смотрим шире
+		lonSpan := 100
+		if cos := math.Cos(p.Lat * math.Pi / 180); cos > 0.01 {
+			lonSpan = int(math.Ceil(1 / cos))
+		}
+
+		tooClose := false
+		for dy := -1; dy <= 1 && !tooClose; dy++ {
+			for dx := -lonSpan; dx <= lonSpan && !tooClose; dx++ {
+				for _, i := range grid[gridCell{c.x + dx, c.y + dy}] {
+					if haversineMeters(p.Lat, p.Lon, kept[i].Lat, kept[i].Lon) < minDist {
+						tooClose = true
+						break
+					}
+				}
+			}
+		}
+		if tooClose {
+			continue
+		}
+
+		grid[c] = append(grid[c], len(kept))
+		kept = append(kept, p)
+	}
+	return kept
+}
+
+// snapToGrid — переносит координату в центр ячейки сетки с шагом step градусов.
+// Это намеренное пространственное огрубление (приватность), а не округление для отображения.
+func snapToGrid(v, step float64) float64 {
+	return math.Floor(v/step)*step + step/2
+}
+
+// dedupKeyPrecision — точность ключа дедупликации (≈ 1 см)
+const dedupKeyPrecision = 1e7
+
+type coordKey struct {
+	lat, lon int64
+}
+
+// dedupToleranceMeters — допуск дедупликации по умолчанию в метрах (DEDUP_TOLERANCE_METERS);
+// 0 — схлопываются только совпадающие координаты
+var dedupToleranceMeters float64
+
+// maxDedupToleranceMeters — больший допуск — уже не дедупликация, а кластеризация
+const maxDedupToleranceMeters = 1000
+
+// dedupKey — ключ дедупликации: без допуска — координаты с точностью ≈ 1 см,
+// с допуском — ячейка сетки со стороной tolerance метров. Ширина ячейки по долготе
+// берётся по широте её ряда, чтобы ячейки были примерно квадратными на любой широте.
+// Две точки ближе tolerance могут оказаться в соседних ячейках — как у любой сетки.
+func dedupKey(lat, lon, tolerance float64) coordKey {
+	if tolerance <= 0 {
+		return coordKey{int64(math.Round(lat * dedupKeyPrecision)), int64(math.Round(lon * dedupKeyPrecision))}
+	}
+	latStep := tolerance / metersPerDegreeLat
+	row := math.Floor(lat / latStep)
+	cos := math.Max(math.Cos((row+0.5)*latStep*math.Pi/180), 0.01)
+	lonStep := latStep / cos
+	return coordKey{int64(row), int64(math.Floor(lon / lonStep))}
+}
+
+// dedupPoints — схлопывает точки с совпадающими (с точностью до tolerance метров) координатами
+// в одну (первую по порядку) и записывает в Count, сколько лотов в ней объединено
+func dedupPoints(points []LotPoint, tolerance float64) []LotPoint {
+	index := make(map[coordKey]int)
+	out := make([]LotPoint, 0, len(points))
+	for _, p := range points {
+		k := dedupKey(p.Lat, p.Lon, tolerance)
+		if i, ok := index[k]; ok {
+			out[i].Count++
+			continue
+		}
+		index[k] = len(out)
+		p.Count = 1
+		out = append(out, p)
+	}
+	return out
+}
+
+// bbox — прямоугольная область: minLon, minLat, maxLon, maxLat
+type bbox [4]float64
+
+// parseBBox — разбирает "minLon,minLat,maxLon,maxLat"
+func parseBBox(s string) (bbox, error) {
+	var b bbox
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return b, errors.New("ожидается minLon,minLat,maxLon,maxLat")
+	}
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return b, fmt.Errorf("некорректное число %q", part)
+		}
+		b[i] = v
+	}
+	if b[0] >= b[2] || b[1] >= b[3] || b[1] < -90 || b[3] > 90 || b[0] < -180 || b[2] > 180 {
+		return b, errors.New("некорректные границы области")
+	}
+	return b, nil
+}
+
+// contains — точка внутри области (границы включительно)
+func (b bbox) contains(lat, lon float64) bool {
+	return lon >= b[0] && lon <= b[2] && lat >= b[1] && lat <= b[3]
+}
+
+// boundsOf — наименьшая область, содержащая все точки
+func boundsOf(points []LotPoint) (bbox, bool) {
+	if len(points) == 0 {
+		return bbox{}, false
+	}
+	b := bbox{points[0].Lon, points[0].Lat, points[0].Lon, points[0].Lat}
+	for _, p := range points[1:] {
+		b[0], b[1] = math.Min(b[0], p.Lon), math.Min(b[1], p.Lat)
+		b[2], b[3] = math.Max(b[2], p.Lon), math.Max(b[3], p.Lat)
+	}
+	return b, true
+}
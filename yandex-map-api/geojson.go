@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// geoJSONCollection — FeatureCollection (RFC 7946) для format=geojson.
+// Truncated — нестандартный «посторонний член» (foreign member, RFC 7946 §6.1):
+// true, если часть точек отброшена из-за maxFeatures. Инструменты, не знающие его, просто игнорируют.
+type geoJSONCollection struct {
+	Type      string           `json:"type"`
+	Features  []geoJSONFeature `json:"features"`
+	Truncated bool             `json:"truncated,omitempty"`
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   geoJSONPoint           `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geoJSONPoint struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"` // [lon, lat]
+}
+
+// writeGeoJSON — отдаёт точки как FeatureCollection; maxFeatures > 0 ограничивает число объектов
+func writeGeoJSON(w http.ResponseWriter, points []LotPoint, maxFeatures int) error {
+	fc := geoJSONCollection{Type: "FeatureCollection", Features: make([]geoJSONFeature, 0, len(points))}
+	if maxFeatures > 0 && len(points) > maxFeatures {
+		points = points[:maxFeatures]
+		fc.Truncated = true
+	}
+	for _, p := range points {
+		props := pointMap(p)
+		delete(props, outputName("lat"))
+		delete(props, outputName("lon"))
+		fc.Features = append(fc.Features, geoJSONFeature{
+			Type:       "Feature",
+			Geometry:   geoJSONPoint{Type: "Point", Coordinates: [2]float64{p.Lon, p.Lat}},
+			Properties: props,
+		})
+	}
+	w.Header().Set("Content-Type", "application/geo+json")
+	return json.NewEncoder(w).Encode(fc)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"html"
+	"net/http"
+	"strings"
+)
+
+// gmapsResponse — точки для Google Maps JS API (format=gmaps): каждый элемент markers
+// передаётся в new google.maps.Marker({...marker, map}) или AdvancedMarkerElement
+type gmapsResponse struct {
+	Markers []gmapsMarker `json:"markers"`
+}
+
+type gmapsMarker struct {
+	Position gmapsLatLng `json:"position"`
+	Title    string      `json:"title"`
+	// Content — HTML для google.maps.InfoWindow (значения экранированы)
+	Content string `json:"content"`
+	ZIndex  int    `json:"zIndex"`
+	ID      string `json:"id,omitempty"`
+}
+
+// gmapsLatLng — LatLngLiteral: у Google долгота называется lng
+type gmapsLatLng struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// writeGMaps — отдаёт точки в формате, удобном для Google Maps
+func writeGMaps(w http.ResponseWriter, points []LotPoint) error {
+	resp := gmapsResponse{Markers: make([]gmapsMarker, 0, len(points))}
+	for _, p := range points {
+		title := p.Title
+		if title == "" {
+			title = p.LotName
+		}
+		resp.Markers = append(resp.Markers, gmapsMarker{
+			Position: gmapsLatLng{Lat: p.Lat, Lng: p.Lon},
+			Title:    title,
+			Content:  gmapsContent(p),
+			ZIndex:   p.Priority,
+			ID:       p.ID,
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(resp)
+}
+
+// gmapsContent — содержимое InfoWindow: название, описание и ссылка, как в балуне Яндекс.Карт
+func gmapsContent(p LotPoint) string {
+	var b strings.Builder
+	b.WriteString("<div>")
+	if p.LotName != "" {
+		b.WriteString("<strong>" + html.EscapeString(p.LotName) + "</strong>")
+	}
+	if p.LotDescription != "" {
+		b.WriteString("<p>" + html.EscapeString(p.LotDescription) + "</p>")
+	}
+	if p.Link != "" {
+		text := p.LinkText
+		if text == "" {
+			text = p.Link
+		}
+		b.WriteString(`<a href="` + html.EscapeString(p.Link) + `" target="_blank" rel="noopener">` + html.EscapeString(text) + "</a>")
+	}
+	b.WriteString("</div>")
+	return b.String()
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// Чтение небольших листов одним запросом (GRID_DATA_MAX_ROWS).
+//
+// Обычный путь — Values.Get: отдельно строка заголовков, отдельно данные и, при AUTO_HEADER_ROW,
+// ещё Spreadsheets.Get за закреплёнными строками — два-три запроса на обновление, зато ответ
+// компактный (только значения). Spreadsheets.Get с includeGridData отдаёт и свойства листа,
+// и все ячейки сразу — один запрос, но ответ в несколько раз тяжелее (каждая ячейка — объект),
+// поэтому выгоден только для небольших листов. Размер листа известен после чтения, так что
+// решение принимается по числу строк в прошлый раз; первое чтение всегда обычное.
+
+// gridDataFields — что запрашиваем у Spreadsheets.Get: свойства листа и отображаемые значения,
+// как их отдаёт Values.Get по умолчанию (FORMATTED_VALUE)
+const gridDataFields = "sheets(properties(title,gridProperties.frozenRowCount),data.rowData.values.formattedValue)"
+
+// useGridData — читать ли лист одним запросом
+func (l *sheetLoader) useGridData() bool {
+	if l.gridDataMaxRows <= 0 || l.metadataKey != "" {
+		return false
+	}
+	rows := l.sheetRows.Load()
+	return rows > 0 && rows <= int64(l.gridDataMaxRows)
+}
+
+// readGrid — заголовки и строки данных одним запросом Spreadsheets.Get с includeGridData.
+// Строки приводятся к виду ответа Values.Get: пустые ячейки в конце строки и пустые строки
+// в конце листа отбрасываются.
+func (l *sheetLoader) readGrid(ctx context.Context) (headers []string, rows [][]interface{}, headerRow int, err error) {
+	name := l.sheet()
+	var resp *sheets.Spreadsheet
+	err = withSheetsRetry(ctx, "чтение листа целиком", func() (err error) {
+		resp, err = l.service.Spreadsheets.Get(l.sheetID).
+			Ranges(a1Range(name, fmt.Sprintf("1:%d", dataLastRow))).
+			IncludeGridData(true).Fields(gridDataFields).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	var sheet *sheets.Sheet
+	for _, sh := range resp.Sheets {
+		if sh.Properties != nil && sh.Properties.Title == name {
+			sheet = sh
+		}
+	}
+	if sheet == nil {
+		return nil, nil, 0, fmt.Errorf("лист %q отсутствует в ответе", name)
+	}
+
+	headerRow = max(l.headerRow, 1)
+	if gp := sheet.Properties.GridProperties; l.autoHeaderRow && gp != nil && gp.FrozenRowCount > 0 && gp.FrozenRowCount < dataLastRow {
+		headerRow = int(gp.FrozenRowCount)
+	}
+
+	var all [][]interface{}
+	for _, data := range sheet.Data {
+		for _, rd := range data.RowData {
+			var row []interface{}
+			for _, cell := range rd.Values {
+				row = append(row, cell.FormattedValue)
+			}
+			for len(row) > 0 && row[len(row)-1] == "" {
+				row = row[:len(row)-1]
+			}
+			all = append(all, row)
+		}
+	}
+	for len(all) > 0 && len(all[len(all)-1]) == 0 {
+		all = all[:len(all)-1]
+	}
+
+	if len(all) >= headerRow {
+		for _, cell := range all[headerRow-1] {
+			headers = append(headers, cellToString(cell))
+		}
+		rows = all[headerRow:]
+	}
+	return headers, rows, headerRow, nil
+}
+
+// loadGrid — load для небольших листов. ok=false — лист не найден, нужен обычный путь
+// (он умеет переключаться на запасной лист).
+func (l *sheetLoader) loadGrid(ctx context.Context) (data *dataset, ok bool, err error) {
+	headers, rows, headerRow, err := l.readGrid(ctx)
+	if err != nil {
+		if isSheetNotFound(err) {
+			return nil, false, nil
+		}
+		log.Printf("❌ Ошибка чтения листа: %v", err)
+		return nil, true, &loadError{http.StatusInternalServerError, "Ошибка чтения данных", err}
+	}
+	l.sheetRows.Store(int64(headerRow + len(rows)))
+
+	cols, err := l.findColumns(headers)
+	if err != nil {
+		return nil, true, err
+	}
+	data, err = l.parseRows(ctx, cols, rows, headerRow+1)
+	return data, true, err
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// otherGroupKey — группа для точек без значения и для схлопнутых мелких групп
+const otherGroupKey = "other"
+
+// pointGroup — точки с одинаковым значением поля группировки
+type pointGroup struct {
+	Key    string     `json:"key"`
+	Count  int        `json:"count"`
+	Points []LotPoint `json:"points"`
+}
+
+// groupedResponse — ответ /api/points?groupBy=...
+type groupedResponse struct {
+	Groups []pointGroup `json:"groups"`
+	Meta   groupsMeta   `json:"meta"`
+}
+
+type groupsMeta struct {
+	GroupBy    string `json:"groupBy"`
+	GroupCount int    `json:"groupCount"`
+	// Collapsed — сколько мелких групп объединено в "other" из-за MAX_GROUPS
+	Collapsed int `json:"collapsed,omitempty"`
+}
+
+// groupableFields — поля, по которым можно группировать и строить фасеты
+var groupableFields = map[string]func(p LotPoint) string{
+	"category":    func(p LotPoint) string { return p.Category },
+	"subcategory": func(p LotPoint) string { return p.Subcategory },
+	"region":      func(p LotPoint) string { return p.Region },
+	"status":      func(p LotPoint) string { return p.Status },
+}
+
+// groupableFieldNames — имена groupableFields по алфавиту, для сообщений клиенту
+func groupableFieldNames() []string {
+	names := make([]string, 0, len(groupableFields))
+	for name := range groupableFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// groupPoints — группирует точки по полю. Группы упорядочены по убыванию размера,
+// при равенстве — по ключу. Если групп больше maxGroups, самые мелкие объединяются в "other".
+func groupPoints(points []LotPoint, field string, maxGroups int) groupedResponse {
+	keyOf := groupableFields[field]
+	index := make(map[string]int)
+	var groups []pointGroup
+	for _, p := range points {
+		key := keyOf(p)
+		if key == "" {
+			key = otherGroupKey
+		}
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, pointGroup{Key: key})
+		}
+		groups[i].Points = append(groups[i].Points, p)
+		groups[i].Count++
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].Key < groups[j].Key
+	})
+
+	meta := groupsMeta{GroupBy: field}
+	if maxGroups > 0 && len(groups) > maxGroups {
+		// Оставляем maxGroups-1 крупнейших групп, остальное — в "other"
+		other := pointGroup{Key: otherGroupKey}
+		var kept []pointGroup
+		for i, g := range groups {
+			if i < maxGroups-1 && g.Key != otherGroupKey {
+				kept = append(kept, g)
+				continue
+			}
+			if g.Key != otherGroupKey {
+				meta.Collapsed++
+			}
+			other.Points = append(other.Points, g.Points...)
+			other.Count += g.Count
+		}
+		groups = append(kept, other)
+		log.Printf("⚠️ Группировка по %s: групп больше MAX_GROUPS=%d, %d мелких объединены в %q",
+			field, maxGroups, meta.Collapsed, otherGroupKey)
+	}
+
+	meta.GroupCount = len(groups)
+	return groupedResponse{Groups: groups, Meta: meta}
+}
+
+// parseGroupFields — разбирает groupBy: одно или несколько полей из groupableFields через запятую
+func parseGroupFields(s string) ([]string, error) {
+	var fields []string
+	seen := make(map[string]bool)
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		if _, ok := groupableFields[f]; !ok {
+			return nil, fmt.Errorf("неизвестное поле %q", f)
+		}
+		if seen[f] {
+			return nil, fmt.Errorf("поле %q указано дважды", f)
+		}
+		seen[f] = true
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// pointTreeNode — узел дерева групп: вложенные группы по следующему полю
+// или, на последнем уровне, сами точки
+type pointTreeNode struct {
+	Key    string          `json:"key"`
+	Count  int             `json:"count"`
+	Groups []pointTreeNode `json:"groups,omitempty"`
+	Points []LotPoint      `json:"points,omitempty"`
+}
+
+// treeResponse — ответ /api/points?groupBy=поле1,поле2,...
+type treeResponse struct {
+	Groups []pointTreeNode `json:"groups"`
+	Meta   groupsMeta      `json:"meta"`
+}
+
+// groupPointsTree — группировка по одному полю (groupedResponse) или по нескольким —
+// вложенным деревом (treeResponse)
+func groupPointsTree(points []LotPoint, fields []string, maxGroups int) interface{} {
+	if len(fields) == 1 {
+		return groupPoints(points, fields[0], maxGroups)
+	}
+	groups, collapsed := buildTree(points, fields, maxGroups)
+	return treeResponse{Groups: groups, Meta: groupsMeta{
+		GroupBy: strings.Join(fields, ","), GroupCount: len(groups), Collapsed: collapsed,
+	}}
+}
+
+// buildTree — группирует точки по первому полю как groupPoints (тот же порядок и MAX_GROUPS
+// на каждом уровне, пустые значения — в "other"), затем каждую группу — по остальным полям.
+// collapsed — сколько групп объединено в "other" на всех уровнях.
+func buildTree(points []LotPoint, fields []string, maxGroups int) (nodes []pointTreeNode, collapsed int) {
+	flat := groupPoints(points, fields[0], maxGroups)
+	collapsed = flat.Meta.Collapsed
+	nodes = make([]pointTreeNode, len(flat.Groups))
+	for i, g := range flat.Groups {
+		nodes[i] = pointTreeNode{Key: g.Key, Count: g.Count}
+		if len(fields) > 1 {
+			var n int
+			nodes[i].Groups, n = buildTree(g.Points, fields[1:], maxGroups)
+			collapsed += n
+		} else {
+			nodes[i].Points = g.Points
+		}
+	}
+	return nodes, collapsed
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gvizSource — чтение таблицы через gviz/tq без сервисного аккаунта (GVIZ_MODE=true).
+//
+// Ограничения:
+//   - работает только для таблиц, открытых «всем, у кого есть ссылка», или опубликованных в вебе;
+//   - заголовки определяет сам Google (headers=1 — первая строка); при смешанных типах
+//     в колонке он может не распознать заголовок или вернуть пустые значения;
+//   - гиперссылки ячеек (LINK_READ_HYPERLINKS) и developer metadata недоступны.
+type gvizSource struct {
+	client    *http.Client
+	sheetID   string
+	sheetName string
+}
+
+// gvizResponse — ответ gviz/tq (только нужные нам поля)
+type gvizResponse struct {
+	Status string `json:"status"`
+	Errors []struct {
+		Reason          string `json:"reason"`
+		Message         string `json:"message"`
+		DetailedMessage string `json:"detailed_message"`
+	} `json:"errors"`
+	Table struct {
+		Cols []struct {
+			Label string `json:"label"`
+		} `json:"cols"`
+		Rows []struct {
+			C []*gvizCell `json:"c"`
+		} `json:"rows"`
+	} `json:"table"`
+}
+
+// gvizCell — ячейка gviz: сырое значение и отформатированное представление
+type gvizCell struct {
+	V interface{} `json:"v"`
+	F string      `json:"f"`
+}
+
+// gvizBaseURL — адрес таблиц Google (в тестах подменяется httptest-сервером)
+var gvizBaseURL = "https://docs.google.com/spreadsheets/d/"
+
+// gvizTimeout — предел чтения опубликованной таблицы, если у контекста нет своего срока
+const gvizTimeout = 30 * time.Second
+
+func newGvizSource(sheetID, sheetName string) *gvizSource {
+	return &gvizSource{
+		client:    &http.Client{},
+		sheetID:   sheetID,
+		sheetName: sheetName,
+	}
+}
+
+// fetch — читает лист и возвращает заголовки и строки в той же модели, что и Sheets API:
+// каждая ячейка — строка (отформатированное значение), пустые ячейки — "".
+func (g *gvizSource) fetch(ctx context.Context) ([]string, [][]interface{}, error) {
+	q := url.Values{}
+	q.Set("tqx", "out:json")
+	q.Set("headers", "1")
+	q.Set("sheet", g.sheetName)
+	u := gvizBaseURL + url.PathEscape(g.sheetID) + "/gviz/tq?" + q.Encode()
+
+	// Срок задаёт запрос (ENDPOINT_TIMEOUTS); собственный таймаут — только если его нет,
+	// чтобы не ждать дольше клиента и не обрывать чтение раньше него
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, gvizTimeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<20))
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("gviz: HTTP %d (таблица опубликована?)", resp.StatusCode)
+	}
+	return parseGviz(body)
+}
+
+// parseGviz — снимает обёртку /*O_o*/ google.visualization.Query.setResponse(...);
+// и разбирает таблицу
+func parseGviz(body []byte) ([]string, [][]interface{}, error) {
+	payload, err := unwrapGviz(string(body))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var gr gvizResponse
+	if err := json.Unmarshal([]byte(payload), &gr); err != nil {
+		return nil, nil, fmt.Errorf("gviz: некорректный JSON: %w", err)
+	}
+	if gr.Status == "error" {
+		msg := "неизвестная ошибка"
+		if len(gr.Errors) > 0 {
+			msg = gr.Errors[0].Reason + ": " + gr.Errors[0].DetailedMessage
+		}
+		return nil, nil, errors.New("gviz: " + msg)
+	}
+
+	headers := make([]string, len(gr.Table.Cols))
+	for i, c := range gr.Table.Cols {
+		headers[i] = c.Label
+	}
+
+	rows := make([][]interface{}, 0, len(gr.Table.Rows))
+	for _, r := range gr.Table.Rows {
+		row := make([]interface{}, len(r.C))
+		for i, c := range r.C {
+			row[i] = gvizCellString(c)
+		}
+		rows = append(rows, row)
+	}
+	return headers, rows, nil
+}
+
+// unwrapGviz — достаёт JSON из ответа вида "/*O_o*/\ngoogle.visualization.Query.setResponse({...});"
+func unwrapGviz(s string) (string, error) {
+	const marker = "setResponse("
+	start := strings.Index(s, marker)
+	if start == -1 {
+		// Иногда ответ приходит без обёртки — тогда это должен быть чистый JSON
+		if t := strings.TrimSpace(s); strings.HasPrefix(t, "{") {
+			return t, nil
+		}
+		return "", errors.New("gviz: неожиданный формат ответа")
+	}
+	s = s[start+len(marker):]
+	end := strings.LastIndex(s, ")")
+	if end == -1 {
+		return "", errors.New("gviz: обрезанный ответ")
+	}
+	return s[:end], nil
+}
+
+// gvizCellString — значение ячейки gviz как строка: отформатированное, если есть, иначе сырое
+func gvizCellString(c *gvizCell) string {
+	if c == nil || c.V == nil {
+		return ""
+	}
+	if c.F != "" {
+		return c.F
+	}
+	switch v := c.V.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
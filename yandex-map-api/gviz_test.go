@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// gvizTestServer — httptest-сервер вместо docs.google.com, считающий обращения
+func gvizTestServer(t *testing.T, handler http.HandlerFunc) *atomic.Int32 {
+	t.Helper()
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		handler(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	saved := gvizBaseURL
+	gvizBaseURL = srv.URL + "/"
+	t.Cleanup(func() { gvizBaseURL = saved })
+	return &requests
+}
+
+func TestGvizFetchExpiredDeadline(t *testing.T) {
+	requests := gvizTestServer(t, func(w http.ResponseWriter, r *http.Request) {})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	if _, _, err := newGvizSource("sheet", "Лист1").fetch(ctx); err == nil {
+		t.Fatal("fetch с истёкшим сроком завершился без ошибки")
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("обращений к серверу %d, ожидалось 0", n)
+	}
+}
+
+func TestGvizFetchUsesRequestDeadline(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	gvizTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, _, err := newGvizSource("sheet", "Лист1").fetch(ctx); err == nil {
+		t.Fatal("fetch медленного сервера завершился без ошибки")
+	}
+	// Срок запроса, а не gvizTimeout: ответ не должен ждать 30 секунд
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("fetch прерван через %v, а срок запроса — 50 мс", elapsed)
+	}
+}
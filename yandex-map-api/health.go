@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// defaultHealthPaths — пути проверки здоровья, если HEALTH_PATHS не задан
+var defaultHealthPaths = []string{"/health", "/healthz"}
+
+// healthSubpaths — подпути, которые регистрируются для каждого пути проверки здоровья
+var healthSubpaths = []string{"", "/detail", "/ready", "/live"}
+
+// warmupTimeout — ограничение одной попытки прогрева
+const warmupTimeout = 60 * time.Second
+
+// healthChecks — проверки здоровья: общая, подробная, готовность и живость
+type healthChecks struct {
+	cache *pointCache
+	// editor — последний редактор таблицы для /detail (nil — не показывать)
+	editor *editorSource
+	// ready — данные прогреты, можно принимать трафик
+	ready atomic.Bool
+	// maintenance — режим обслуживания (nil — не настроен)
+	maintenance *maintenanceMode
+	// sources — чтение нескольких таблиц (nil — таблица одна)
+	sources *multiSource
+}
+
+// parseHealthPaths — разбирает HEALTH_PATHS: "/health,/healthz,/readyz"
+func parseHealthPaths(s string) ([]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return defaultHealthPaths, nil
+	}
+	var paths []string
+	seen := make(map[string]bool)
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimRight(strings.TrimSpace(p), "/")
+		if p == "" {
+			continue
+		}
+		if !strings.HasPrefix(p, "/") || strings.HasPrefix(p, "/api/") {
+			return nil, fmt.Errorf("некорректный путь %q", p)
+		}
+		if !seen[p] {
+			seen[p] = true
+			paths = append(paths, p)
+		}
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("не задано ни одного пути")
+	}
+	return paths, nil
+}
+
+// register — регистрирует одинаковый набор проверок на каждом из путей
+func (h *healthChecks) register(paths []string) {
+	for _, p := range paths {
+		http.HandleFunc(p, h.handle)
+		http.HandleFunc(p+"/detail", h.detail)
+		http.HandleFunc(p+"/ready", h.readiness)
+		http.HandleFunc(p+"/live", h.liveness)
+	}
+}
+
+func (h *healthChecks) handle(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	resp := map[string]string{"status": "ok"}
+	if h.maintenance.active() {
+		resp["maintenance"] = "true"
+	}
+	// Пустая таблица и недоступная различаются: тревога нужна только на error
+	if state := h.cache.status().Sheets; state != "" {
+		resp["sheets"] = state
+	}
+	if next := sheetsNextAvailable(); !next.IsZero() {
+		resp["sheetsNextAvailable"] = next.Format(time.RFC3339)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// detail — подробное состояние: свежесть кэша без обращения к /api/points
+func (h *healthChecks) detail(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	resp := struct {
+		Status              string         `json:"status"`
+		Ready               bool           `json:"ready"`
+		Cache               cacheStatus    `json:"cache"`
+		SheetsNextAvailable *time.Time     `json:"sheetsNextAvailable,omitempty"`
+		LastEditor          *lastEditor    `json:"lastEditor,omitempty"`
+		Maintenance         bool           `json:"maintenance,omitempty"`
+		Sources             []sourceStatus `json:"sources,omitempty"`
+	}{Status: "ok", Ready: h.ready.Load(), Cache: h.cache.status(), LastEditor: h.editor.get(r.Context()),
+		Maintenance: h.maintenance.active(), Sources: h.sources.status()}
+	if next := sheetsNextAvailable(); !next.IsZero() {
+		resp.SheetsNextAvailable = &next
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// readiness — 503, пока не завершён прогрев: балансировщик не пускает трафик раньше времени.
+// В режиме обслуживания тоже 503 — балансировщик выводит экземпляр из ротации.
+func (h *healthChecks) readiness(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if h.maintenance.active() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"status": "maintenance"})
+		return
+	}
+	if !h.ready.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"status": "warming_up"})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// liveness — процесс жив и отвечает. Режим обслуживания на живость не влияет,
+// если не задан MAINTENANCE_FAILS_LIVENESS.
+func (h *healthChecks) liveness(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if h.maintenance != nil && h.maintenance.failsLiveness && h.maintenance.active() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]string{"status": "maintenance"})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// warmup — заполняет кэш до приёма трафика, повторяя попытки до успеха,
+// и после этого открывает /health/ready
+func (h *healthChecks) warmup(ctx context.Context) {
+	start := time.Now()
+	delay := time.Second
+	for {
+		attemptCtx, cancel := context.WithTimeout(ctx, warmupTimeout)
+		_, err := h.cache.get(attemptCtx)
+		cancel()
+		if err == nil {
+			break
+		}
+		log.Printf("⚠️ Прогрев не удался, повтор через %v: %v", delay, err)
+		if sleepCtx(ctx, delay) != nil {
+			return
+		}
+		delay = min(delay*2, 30*time.Second)
+	}
+	h.ready.Store(true)
+	log.Printf("✅ Прогрев завершён за %v, принимаем трафик", time.Since(start).Round(time.Millisecond))
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxRequestedIDs — сколько идентификаторов можно перечислить в ids за один запрос
+const maxRequestedIDs = 500
+
+// parseIDList — разбирает ids=a,b,c; пустые элементы и повторы отбрасываются, порядок сохраняется
+func parseIDList(s string) ([]string, error) {
+	var ids []string
+	seen := make(map[string]bool)
+	for _, id := range strings.Split(s, ",") {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("пустой список")
+	}
+	if len(ids) > maxRequestedIDs {
+		return nil, fmt.Errorf("не больше %d идентификаторов", maxRequestedIDs)
+	}
+	return ids, nil
+}
+
+// selectByIDs — точки с перечисленными идентификаторами в порядке запроса и идентификаторы,
+// которых в таблице нет (устаревшие ссылки). При повторах ID в таблице берётся первая строка.
+func selectByIDs(points []LotPoint, ids []string) (selected []LotPoint, missing []string) {
+	index := make(map[string]int, len(points))
+	for i, p := range points {
+		if _, dup := index[p.ID]; p.ID != "" && !dup {
+			index[p.ID] = i
+		}
+	}
+	selected = make([]LotPoint, 0, len(ids))
+	for _, id := range ids {
+		i, ok := index[id]
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+		selected = append(selected, points[i])
+	}
+	return selected, missing
+}
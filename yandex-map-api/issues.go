@@ -0,0 +1,40 @@
+package main
+
+// Коды проблем в строках таблицы
+const (
+	issueNoLotInfo     = "no_lot_info"
+	issueInvalidJSON   = "invalid_lot_info"
+	issueNoCoordinates = "no_coordinates"
+	issueEmptyName     = "empty_name"
+	issueInvalidLink   = "invalid_link"
+	issueInvalidValue  = "invalid_value"
+	issueDuplicateID   = "duplicate_id"
+)
+
+// issueSnippetLen — сколько символов значения ячейки сохраняем в описании проблемы
+const issueSnippetLen = 100
+
+// rowIssue — проблема в строке таблицы: строка пропущена или часть данных отсутствует
+type rowIssue struct {
+	Row     int    `json:"row"`
+	Problem string `json:"problem"`
+	Message string `json:"message"`
+	Value   string `json:"value,omitempty"`
+	// Skipped — строка не попала в выдачу
+	Skipped bool `json:"skipped"`
+	// Spreadsheet — ID таблицы, если их несколько (EXTRA_SPREADSHEET_IDS)
+	Spreadsheet string `json:"spreadsheet,omitempty"`
+}
+
+// dataset — результат одной загрузки таблицы
+type dataset struct {
+	Points []LotPoint
+	// Drafts — точки, отмеченные в колонке Draft; в Points не входят (includeDrafts)
+	Drafts []LotPoint
+	// Issues — проблемы разбора строк в порядке строк (для /api/points/incomplete)
+	Issues []rowIssue
+	// Facets — различные значения полей для /api/facets (см. computeFacets)
+	Facets map[string]facetsResponse
+	// HasCreatedAt — в таблице есть колонка Created_at (для frame)
+	HasCreatedAt bool
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+)
+
+// jsonpCallbackRe — допустимое имя функции обратного вызова: JS-идентификатор,
+// возможно с точками (ns.handler). Всё остальное отклоняем, чтобы не допустить инъекции.
+var jsonpCallbackRe = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*(\.[A-Za-z_$][A-Za-z0-9_$]*)*$`)
+
+const maxJSONPCallbackLen = 64
+
+func validJSONPCallback(name string) bool {
+	return len(name) <= maxJSONPCallbackLen && jsonpCallbackRe.MatchString(name)
+}
+
+// writeJSONP — оборачивает JSON в вызов callback(...) для старых встраиваний через <script>.
+// Префикс /**/ защищает от подмены типа содержимого (Rosetta Flash и подобные атаки).
+func writeJSONP(w http.ResponseWriter, callback string, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/javascript; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	_, err = w.Write([]byte("/**/" + callback + "("))
+	if err == nil {
+		_, err = w.Write(body)
+	}
+	if err == nil {
+		_, err = w.Write([]byte(");"))
+	}
+	return err
+}
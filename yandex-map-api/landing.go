@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// version — версия сервиса, задаётся при сборке: -ldflags "-X main.version=1.2.3"
+var version = "dev"
+
+// endpointInfo — описание маршрута для ответа на корневой путь
+type endpointInfo struct {
+	Path        string `json:"path"`
+	Description string `json:"description"`
+}
+
+// apiEndpoints — публичные маршруты API; пополняется вместе с новыми обработчиками
+var apiEndpoints = []endpointInfo{
+	{"/api/points", "Точки лотов (format, limit/offset, sort, groupBy, thin, dedup, hash, ids, frame, q, searchFields, fields, includeDrafts, envelope)"},
+	{"/api/points/explain", "Сколько точек убрал каждый этап отбора (те же параметры, что у /api/points)"},
+	{"/api/points/near", "Ближайшие к точке лоты (lat, lon, radius, units)"},
+	{"/api/points/by-region", "Число точек в регионах из REGIONS_FILE (centroids=true — с центрами)"},
+	{"/api/points/clusters", "Кластеры точек для масштаба (zoom, bbox, cell) с центром ячейки и центром масс"},
+	{"/api/points/density", "Сетка плотности точек (bbox, cols, rows)"},
+	{"/api/points/quadtree", "Дерево квадрантов для постепенной загрузки: в плотных узлах число, в редких точки (bbox, depth, threshold)"},
+	{"/api/facets/{field}", "Различные значения поля со счётчиками (category, subcategory, region, status)"},
+	{"/api/tiles/{z}/{x}/{y}", "Точки в тайле XYZ (format=json|mvt)"},
+	{"/api/config", "Настройки карты для фронтенда (ключ JS API, центр, масштаб, типы карты)"},
+	{"/metrics", "Метрики в формате Prometheus"},
+}
+
+// landingHandler — GET / : краткое описание сервиса, чтобы корневой адрес не отвечал голым 404
+func landingHandler(healthPaths []string) http.HandlerFunc {
+	endpoints := append([]endpointInfo(nil), apiEndpoints...)
+	for _, p := range healthPaths {
+		endpoints = append(endpoints, endpointInfo{p, "Проверка здоровья (/detail, /ready, /live)"})
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"service":   "yandex-map-api",
+			"version":   version,
+			"endpoints": endpoints,
+		})
+	}
+}
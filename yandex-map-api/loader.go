@@ -0,0 +1,894 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/sheets/v4"
+)
+
+// sheetLoader — читает таблицу и разбирает её строки в точки
+type sheetLoader struct {
+	service   *sheets.Service
+	sheetID   string
+	sheetName string
+	// readHyperlinks — брать ссылку из гиперссылки ячейки (=HYPERLINK или вставленная ссылка),
+	// а не отображаемый текст
+	readHyperlinks bool
+	// defaults — значения для пустых ячеек (DEFAULTS)
+	defaults fieldDefaults
+	// metadataKey — ключ developer metadata, которым в таблице помечены строки данных
+	metadataKey string
+	// gviz — чтение опубликованной таблицы без сервисного аккаунта (GVIZ_MODE); service тогда nil
+	gviz *gvizSource
+	// snapGrid — шаг сетки в градусах для огрубления координат (0 — не огрублять)
+	snapGrid float64
+	// title — шаблон поля title (nil — поле не заполняется)
+	title *titleTemplate
+	// pipeline — шаги обработки разобранных точек (PIPELINE_FILE)
+	pipeline pipeline
+
+	// fallbackSheet — лист, на который переключаемся, если sheetName не найден;
+	// fallbackFirst — при отсутствии sheetName брать первый лист таблицы
+	fallbackSheet string
+	fallbackFirst bool
+
+	// headerRow — номер строки заголовков (HEADER_ROW, по умолчанию 1), данные идут со следующей;
+	// autoHeaderRow — брать строку заголовков по закреплённым строкам листа (AUTO_HEADER_ROW)
+	headerRow     int
+	autoHeaderRow bool
+
+	// pageRows — читать диапазон данных страницами по столько строк (0 — одним запросом);
+	// prefetchPages — сколько следующих страниц запрашивать заранее
+	pageRows      int
+	prefetchPages int
+
+	// parseLotInfo — разбор ячейки Lot_info (LOT_INFO_FORMAT, по умолчанию JSON)
+	parseLotInfo lotInfoParser
+
+	// approximatePlacement — лоты без координат, но с известным регионом ставить в центр региона
+	// (APPROXIMATE_PLACEMENT) с пометкой approximate
+	approximatePlacement bool
+	// extraColumns — отдавать нераспознанные колонки в поле extras (EXTRA_COLUMNS);
+	// duplicateHeaders — что делать с повторяющимися заголовками (DUPLICATE_HEADERS)
+	extraColumns     bool
+	duplicateHeaders string
+	// coordPriority — порядок источников координат (COORD_SOURCE_PRIORITY, nil — по умолчанию)
+	coordPriority []string
+	// retryParseCell — при ошибке разбора Lot_info перечитать ячейку один раз (RETRY_PARSE_CELL)
+	retryParseCell bool
+	// autoFixCoordOrder — менять местами широту и долготу, если они явно перепутаны (AUTO_FIX_COORD_ORDER)
+	autoFixCoordOrder bool
+
+	// mapping — заголовки колонок из файла сопоставления (FIELD_MAPPING)
+	mapping fieldMapping
+
+	// priceCurrency — валюта цен без обозначения валюты (PRICE_DEFAULT_CURRENCY, "" — не указывать)
+	priceCurrency string
+
+	// gridDataMaxRows — листы не больше стольких строк читать одним Spreadsheets.Get (0 — никогда)
+	gridDataMaxRows int
+
+	// Состояние чтения — своё у каждой таблицы (см. forSpreadsheet), поэтому по указателю
+	*sheetState
+}
+
+// sheetState — что загрузчик запоминает между чтениями таблицы
+type sheetState struct {
+	// sheetRows — сколько строк было в листе при прошлом чтении (0 — ещё не читали)
+	sheetRows atomic.Int64
+
+	sheetMu     sync.RWMutex
+	activeSheet string // лист, выбранный вместо sheetName после переключения
+}
+
+// Диапазон данных по умолчанию: со 2-й строки (после заголовков в 1-й)
+const (
+	dataFirstRow = 2
+	dataLastRow  = 10000 // можно увеличить при необходимости
+)
+
+// load — читает заголовки и данные таблицы и возвращает точки в порядке строк.
+// Исходные значения строк сохраняются в Raw; отдавать их клиенту или нет, решает обработчик.
+func (l *sheetLoader) load(ctx context.Context) (*dataset, error) {
+	return l.loadRange(ctx, "")
+}
+
+// loadRange — как load, но строки данных читаются из явно заданного A1-диапазона
+// текущего листа (пустая строка — диапазон по умолчанию). Только для Sheets API.
+func (l *sheetLoader) loadRange(ctx context.Context, override string) (*dataset, error) {
+	if l.gviz != nil {
+		headers, rows, err := l.gviz.fetch(ctx)
+		if err != nil {
+			log.Printf("❌ Ошибка чтения опубликованной таблицы (gviz): %v", err)
+			return nil, &loadError{http.StatusInternalServerError, "Ошибка чтения данных", err}
+		}
+		cols, err := l.findColumns(headers)
+		if err != nil {
+			return nil, err
+		}
+		return l.parseRows(ctx, cols, rows, dataFirstRow)
+	}
+
+	// Небольшой лист — одним запросом вместе со свойствами (GRID_DATA_MAX_ROWS)
+	if override == "" && l.useGridData() {
+		if data, ok, err := l.loadGrid(ctx); ok {
+			return data, err
+		}
+	}
+
+	// 1. Читаем строку заголовков
+	headers, headerRow, err := l.readHeaders(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Ищем индексы нужных колонок
+	cols, err := l.findColumns(headers)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Читаем все данные
+	rows, startRow, err := l.readDataRows(ctx, override, headerRow+1)
+	if err != nil {
+		log.Printf("❌ Ошибка чтения данных: %v", err)
+		return nil, &loadError{http.StatusInternalServerError, "Ошибка чтения данных", err}
+	}
+	if override == "" {
+		l.sheetRows.Store(int64(startRow - 1 + len(rows)))
+	}
+
+	return l.parseRows(ctx, cols, rows, startRow)
+}
+
+// readHeaders — заголовки листа и номер их строки. Если лист не найден,
+// переключается на запасной (см. fallbackSheetName).
+func (l *sheetLoader) readHeaders(ctx context.Context) ([]string, int, error) {
+	if l.gviz != nil {
+		headers, _, err := l.gviz.fetch(ctx)
+		return headers, dataFirstRow - 1, err
+	}
+
+	headerRow := l.resolveHeaderRow(ctx)
+	headerResp, err := l.readHeaderRow(ctx, headerRow)
+	if err != nil && isSheetNotFound(err) {
+		if name, ok := l.fallbackSheetName(ctx); ok {
+			l.setActiveSheet(name)
+			headerRow = l.resolveHeaderRow(ctx)
+			headerResp, err = l.readHeaderRow(ctx, headerRow)
+		} else {
+			return nil, 0, &loadError{http.StatusInternalServerError, fmt.Sprintf("Лист %q не найден в таблице", l.sheet()), err}
+		}
+	}
+	if err != nil {
+		log.Printf("❌ Ошибка чтения заголовков: %v", err)
+		return nil, 0, &loadError{http.StatusInternalServerError, "Ошибка чтения структуры таблицы", err}
+	}
+
+	var headers []string
+	if len(headerResp.Values) > 0 {
+		for i, cell := range headerResp.Values[0] {
+			str := cellToString(cell)
+			switch {
+			case strings.TrimSpace(str) == "":
+				log.Printf("⚠️ Пустой заголовок в колонке %s (строка %d)", columnLetter(i), headerRow)
+			case !isStringCell(cell):
+				log.Printf("⚠️ Заголовок колонки %s (строка %d) не текстовый (%T), используем %q", columnLetter(i), headerRow, cell, str)
+			}
+			headers = append(headers, str)
+		}
+	}
+	return headers, headerRow, nil
+}
+
+// columnIndexes — индексы распознанных колонок (-1 — колонки нет)
+type columnIndexes struct {
+	lotInfo, link, priority int
+	thumb, image, category  int
+	region, status, weight  int
+	id                      int
+	lat, lon, coords        int
+	linkText, createdAt     int
+	draft, subcategory      int
+	price                   int
+	// extras — колонки, отдаваемые как есть в поле extras (EXTRA_COLUMNS, DUPLICATE_HEADERS=suffix)
+	extras []extraColumn
+}
+
+// extraColumn — колонка для extras: имя ключа и индекс
+type extraColumn struct {
+	name  string
+	index int
+}
+
+// Обработка повторяющихся заголовков (DUPLICATE_HEADERS)
+const (
+	// duplicateHeadersPick — предупредить и использовать последнюю из одноимённых колонок
+	duplicateHeadersPick = "pick"
+	// duplicateHeadersSuffix — первая колонка используется как обычно, следующие попадают
+	// в extras с суффиксом: note, note_2, note_3
+	duplicateHeadersSuffix = "suffix"
+)
+
+// columnSpec — логическое поле, его стандартные заголовки и куда записать индекс колонки
+type columnSpec struct {
+	field   string
+	aliases []string
+	index   func(*columnIndexes) *int
+}
+
+// columnSpecs — распознаваемые колонки; имена полей используются в файле сопоставления (FIELD_MAPPING)
+var columnSpecs = []columnSpec{
+	{"lotInfo", []string{"lot_info", "lot info"}, func(c *columnIndexes) *int { return &c.lotInfo }},
+	{"link", []string{"link"}, func(c *columnIndexes) *int { return &c.link }},
+	{"linkText", []string{"link_text", "link text"}, func(c *columnIndexes) *int { return &c.linkText }},
+	{"priority", []string{"priority", "zindex"}, func(c *columnIndexes) *int { return &c.priority }},
+	{"thumbUrl", []string{"thumb_url", "thumb url"}, func(c *columnIndexes) *int { return &c.thumb }},
+	{"imageUrl", []string{"full_url", "full url"}, func(c *columnIndexes) *int { return &c.image }},
+	{"category", []string{"category", "категория"}, func(c *columnIndexes) *int { return &c.category }},
+	{"subcategory", []string{"subcategory", "подкатегория"}, func(c *columnIndexes) *int { return &c.subcategory }},
+	{"region", []string{"region", "регион", "city", "город"}, func(c *columnIndexes) *int { return &c.region }},
+	{"status", []string{"status", "статус"}, func(c *columnIndexes) *int { return &c.status }},
+	{"weight", []string{"weight", "вес"}, func(c *columnIndexes) *int { return &c.weight }},
+	{"price", []string{"price", "цена", "стоимость"}, func(c *columnIndexes) *int { return &c.price }},
+	{"id", []string{"id", "lot_id", "lot id"}, func(c *columnIndexes) *int { return &c.id }},
+	{"lat", []string{"lat", "latitude", "широта"}, func(c *columnIndexes) *int { return &c.lat }},
+	{"lon", []string{"lon", "lng", "longitude", "долгота"}, func(c *columnIndexes) *int { return &c.lon }},
+	{"createdAt", []string{"created_at", "created at", "добавлен"}, func(c *columnIndexes) *int { return &c.createdAt }},
+	{"draft", []string{"draft", "черновик"}, func(c *columnIndexes) *int { return &c.draft }},
+	{"coordinates", []string{"coordinates", "coords", "координаты"}, func(c *columnIndexes) *int { return &c.coords }},
+}
+
+// findColumns — ищет нужные колонки по заголовкам; Lot_info и Link обязательны.
+// Повторяющиеся заголовки обрабатываются по duplicateHeaders, нераспознанные колонки
+// при extraColumns попадают в extras.
+// Для полей из mapping колонка ищется только по заданному там заголовку.
+func (l *sheetLoader) findColumns(headers []string) (columnIndexes, error) {
+	cols := columnIndexes{lotInfo: -1, link: -1, priority: -1, thumb: -1, image: -1, category: -1, region: -1, status: -1, weight: -1, id: -1, lat: -1, lon: -1, coords: -1, linkText: -1, createdAt: -1, draft: -1, subcategory: -1, price: -1}
+	seen := make(map[string]int)     // нормализованный заголовок → сколько раз встретился
+	first := make(map[string]string) // нормализованный заголовок → как он записан в первый раз
+	for i, h := range headers {
+		norm := normalizeHeader(h)
+		if norm == "" {
+			continue
+		}
+		seen[norm]++
+		if _, ok := first[norm]; !ok {
+			first[norm] = strings.TrimSpace(h)
+		}
+		if n := seen[norm]; n > 1 {
+			if l.duplicateHeaders == duplicateHeadersSuffix {
+				cols.extras = append(cols.extras, extraColumn{fmt.Sprintf("%s_%d", first[norm], n), i})
+				continue
+			}
+			log.Printf("⚠️ Заголовок %q повторяется, используется колонка %s", strings.TrimSpace(h), columnLetter(i))
+		}
+
+		matched := false
+		for _, spec := range columnSpecs {
+			if l.mapping.matches(spec, norm) {
+				*spec.index(&cols) = i
+				matched = true
+			}
+		}
+		if !matched && (l.extraColumns || l.duplicateHeaders == duplicateHeadersSuffix) {
+			cols.extras = append(cols.extras, extraColumn{strings.TrimSpace(h), i})
+		}
+	}
+
+	if cols.lotInfo == -1 {
+		log.Println("❌ Колонка 'Lot_info' не найдена в заголовках")
+		return cols, &loadError{http.StatusBadRequest, "Колонка 'Lot_info' не найдена", nil}
+	}
+	if cols.link == -1 {
+		log.Println("❌ Колонка 'Link' не найдена в заголовках")
+		return cols, &loadError{http.StatusBadRequest, "Колонка 'Link' не найдена", nil}
+	}
+	return cols, nil
+}
+
+// parseRows — разбирает строки данных в точки; startRow — номер первой строки в таблице
+// Попутно собирает проблемы строк: пропущенные строки и строки с неполными данными.
+func (l *sheetLoader) parseRows(ctx context.Context, cols columnIndexes, rows [][]interface{}, startRow int) (*dataset, error) {
+	var hyperlinks []string
+	if l.readHyperlinks && l.service != nil {
+		hyperlinks = l.readLinkHyperlinks(ctx, cols.link, startRow, len(rows))
+	}
+
+	var points []LotPoint
+	var issues []rowIssue
+	seenIDs := make(map[string]int) // ID → номер строки, где он встретился первым
+
+	for rowIndex, row := range rows {
+		// Пропускаем пустые строки
+		if len(row) == 0 {
+			continue
+		}
+
+		rowNum := startRow + rowIndex
+		report := func(problem, message, value string, skipped bool) {
+			issues = append(issues, rowIssue{
+				Row: rowNum, Problem: problem, Message: message,
+				Value: truncateRunes(value, issueSnippetLen), Skipped: skipped,
+			})
+		}
+
+		// Получаем значение Lot_info
+		lotInfoStr, _ := cellAt(row, cols.lotInfo).(string)
+		if lotInfoStr == "" {
+			if !rowIsBlank(row) {
+				report(issueNoLotInfo, "Пустая ячейка Lot_info", "", true)
+			}
+			continue // пропускаем, если нет данных
+		}
+
+		// Получаем значение Link
+		linkStr, _ := cellAt(row, cols.link).(string)
+		if rowIndex < len(hyperlinks) && hyperlinks[rowIndex] != "" {
+			linkStr = hyperlinks[rowIndex]
+		}
+		linkStr = l.defaults.or("link", linkStr)
+		if !validURL(linkStr) {
+			report(issueInvalidLink, "Пустая или некорректная ссылка", linkStr, false)
+		}
+
+		// Получаем приоритет (необязательная колонка, по умолчанию 0)
+		priorityStr, _ := cellAt(row, cols.priority).(string)
+		var priority int
+		if s := strings.TrimSpace(l.defaults.or("priority", priorityStr)); s != "" {
+			if f, err := strconv.ParseFloat(s, 64); err == nil {
+				priority = int(f)
+			} else {
+				log.Printf("⚠️ Некорректный приоритет %q в строке %d", s, rowNum)
+				report(issueInvalidValue, "Некорректный приоритет", s, false)
+			}
+		}
+
+		// Получаем ссылки на превью и полноразмерное изображение (необязательные колонки)
+		var thumbURL, imageURL string
+		if s, ok := cellAt(row, cols.thumb).(string); ok && strings.TrimSpace(s) != "" {
+			if validURL(s) {
+				thumbURL = strings.TrimSpace(s)
+			} else {
+				log.Printf("⚠️ Некорректная ссылка на превью %q в строке %d", s, rowNum)
+				report(issueInvalidValue, "Некорректная ссылка на превью", s, false)
+			}
+		}
+		if s, ok := cellAt(row, cols.image).(string); ok && strings.TrimSpace(s) != "" {
+			if validURL(s) {
+				imageURL = strings.TrimSpace(s)
+			} else {
+				log.Printf("⚠️ Некорректная ссылка на изображение %q в строке %d", s, rowNum)
+				report(issueInvalidValue, "Некорректная ссылка на изображение", s, false)
+			}
+		}
+
+		// Текст ссылки для балуна (необязательная колонка Link_text, иначе DEFAULTS)
+		linkText := l.defaults.or("linkText", strings.TrimSpace(cellToString(cellAt(row, cols.linkText))))
+
+		// Получаем категорию (необязательная колонка)
+		category, _ := cellAt(row, cols.category).(string)
+		category = l.defaults.or("category", strings.TrimSpace(category))
+		subcategory, _ := cellAt(row, cols.subcategory).(string)
+		subcategory = l.defaults.or("subcategory", strings.TrimSpace(subcategory))
+
+		// Разбираем Lot_info (JSON или формат из LOT_INFO_FORMAT)
+		parse := l.parseLotInfo
+		if parse == nil {
+			parse = parseLotInfoJSON
+		}
+		lot, err := parse(lotInfoStr)
+		if err != nil && l.retryParseCell && l.service != nil {
+			// Редкий сбой Sheets: ячейка пришла обрезанной. Перечитываем только её.
+			if again, rerr := l.rereadCell(ctx, cols.lotInfo, rowNum); rerr != nil {
+				log.Printf("⚠️ Повторное чтение Lot_info в строке %d не удалось: %v", rowNum, rerr)
+			} else if lot2, err2 := parse(again); err2 == nil {
+				log.Printf("✅ Повторное чтение Lot_info в строке %d помогло: было %d символов, стало %d",
+					rowNum, len([]rune(lotInfoStr)), len([]rune(again)))
+				lotInfoStr, lot, err = again, lot2, nil
+			}
+		}
+		if err != nil {
+			log.Printf("⚠️ Ошибка парсинга Lot_info в строке %d: %v", rowNum, err)
+			report(issueInvalidJSON, "Ошибка разбора Lot_info: "+err.Error(), lotInfoStr, true)
+			continue
+		}
+
+		// Получаем регион и статус (необязательные колонки)
+		region, _ := cellAt(row, cols.region).(string)
+		region = l.defaults.or("region", strings.TrimSpace(region))
+		status, _ := cellAt(row, cols.status).(string)
+		status = l.defaults.or("status", strings.TrimSpace(status))
+
+		// Получаем идентификатор (необязательная колонка ID)
+		id := strings.TrimSpace(cellToString(cellAt(row, cols.id)))
+		if id != "" {
+			if first, dup := seenIDs[id]; dup {
+				report(issueDuplicateID, fmt.Sprintf("ID уже встречался в строке %d", first), id, false)
+			} else {
+				seenIDs[id] = rowNum
+			}
+		}
+
+		// Прочие колонки — как есть (пустые ячейки не передаются)
+		var extras map[string]string
+		for _, ec := range cols.extras {
+			if v := strings.TrimSpace(cellToString(cellAt(row, ec.index))); v != "" {
+				if extras == nil {
+					extras = make(map[string]string, len(cols.extras))
+				}
+				extras[ec.name] = v
+			}
+		}
+
+		// Дата добавления (необязательная колонка Created_at, для анимации по времени)
+		var createdAt *time.Time
+		if s := strings.TrimSpace(cellToString(cellAt(row, cols.createdAt))); s != "" {
+			if t, err := parseCreatedAt(s); err == nil {
+				createdAt = &t
+			} else {
+				report(issueInvalidValue, "Некорректная дата добавления", s, false)
+			}
+		}
+
+		// Черновик (необязательная колонка Draft): виден только уровням доступа с drafts
+		draft := isTruthyCell(cellToString(cellAt(row, cols.draft)))
+
+		// Получаем вес (необязательная колонка, для центров кластеров)
+		var weight float64
+		if s := strings.TrimSpace(cellToString(cellAt(row, cols.weight))); s != "" {
+			if f, err := strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 64); err == nil && f >= 0 {
+				weight = f
+			} else {
+				report(issueInvalidValue, "Некорректный вес", s, false)
+			}
+		}
+
+		// Получаем цену (необязательная колонка): "1,2 млн ₽", "$850K", "950 тыс. руб."
+		var price *float64
+		var currency string
+		if s := strings.TrimSpace(cellToString(cellAt(row, cols.price))); s != "" {
+			if v, c, err := parsePrice(s); err == nil {
+				price, currency = &v, c
+				if currency == "" {
+					currency = l.priceCurrency
+				}
+			} else {
+				log.Printf("⚠️ Строка %d: не удалось разобрать цену %q: %v", rowNum, s, err)
+				report(issueInvalidValue, "Некорректная цена: "+err.Error(), s, false)
+			}
+		}
+
+		// Координаты из первого по приоритету источника; без них строка пропускается.
+		// Центр региона — крайний случай, такая точка помечается approximate.
+		coord, ok := l.resolveCoordinates(lot, row, rowNum, cols, region, report)
+		if !ok {
+			report(issueNoCoordinates, "Нет координат", lotInfoStr, true)
+			continue
+		}
+		lat, lon := coord.lat, coord.lon
+		approximate := coord.source == coordSourceRegion
+		if approximate {
+			report(issueNoCoordinates, "Нет координат, точка поставлена в центр региона "+region, lotInfoStr, false)
+		}
+
+		if l.snapGrid > 0 {
+			lat, lon = snapToGrid(lat, l.snapGrid), snapToGrid(lon, l.snapGrid)
+		}
+
+		point := LotPoint{
+			Lat:            lat,
+			Lon:            lon,
+			LotName:        l.defaults.or("lotName", lot.LotName),
+			LotDescription: l.defaults.or("lotDescription", lot.LotDescription),
+			Link:           linkStr,
+			LinkText:       linkText,
+			Priority:       priority,
+			ThumbURL:       thumbURL,
+			ImageURL:       imageURL,
+			Category:       category,
+			Subcategory:    subcategory,
+			Region:         region,
+			Approximate:    approximate,
+			ID:             id,
+			Status:         status,
+			Weight:         weight,
+			Price:          price,
+			Currency:       currency,
+			Extras:         extras,
+			CreatedAt:      createdAt,
+			Draft:          draft,
+			Raw:            &RawRow{LotInfo: lotInfoStr, Row: row},
+		}
+		if point.LotName == "" {
+			report(issueEmptyName, "Пустое название лота", "", false)
+		}
+		if l.title != nil {
+			point.Title = l.title.render(point)
+		}
+		points = append(points, point)
+	}
+	points = l.pipeline.run(points)
+
+	// Черновики держим отдельно: ни один обработчик не увидит их случайно
+	var drafts []LotPoint
+	if cols.draft >= 0 {
+		published := points[:0]
+		for _, p := range points {
+			if p.Draft {
+				drafts = append(drafts, p)
+			} else {
+				published = append(published, p)
+			}
+		}
+		points = published
+	}
+
+	if len(drafts) > 0 {
+		log.Printf("✅ Загружено %d точек из таблицы и %d черновиков (проблемных строк: %d)", len(points), len(drafts), len(issues))
+	} else {
+		log.Printf("✅ Загружено %d точек из таблицы (проблемных строк: %d)", len(points), len(issues))
+	}
+	return &dataset{Points: points, Drafts: drafts, Issues: issues, Facets: computeFacets(points), HasCreatedAt: cols.createdAt >= 0}, nil
+}
+
+// isTruthyCell — ячейка-флажок отмечена: флажок Sheets (TRUE), «да», «x» и т.п.
+func isTruthyCell(s string) bool {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "true", "1", "yes", "y", "да", "x", "✓", "✔":
+		return true
+	}
+	return false
+}
+
+// cellAt — значение ячейки по индексу колонки или nil, если колонки нет (idx = -1)
+// или Sheets обрезал строку: пустые ячейки в конце строки в ответ не попадают
+func cellAt(row []interface{}, idx int) interface{} {
+	if idx < 0 || idx >= len(row) {
+		return nil
+	}
+	return row[idx]
+}
+
+// cellToString — значение ячейки как текст: числа без лишних нулей (2024, а не 2024.000000),
+// логические — true/false, пустая ячейка — ""
+func cellToString(cell interface{}) string {
+	switch v := cell.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func isStringCell(cell interface{}) bool {
+	_, ok := cell.(string)
+	return ok
+}
+
+// rowIsBlank — во всех ячейках строки пусто
+func rowIsBlank(row []interface{}) bool {
+	for _, cell := range row {
+		if s, ok := cell.(string); !ok || strings.TrimSpace(s) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// sheet — имя листа, из которого читаем данные
+func (l *sheetLoader) sheet() string {
+	l.sheetMu.RLock()
+	defer l.sheetMu.RUnlock()
+	if l.activeSheet != "" {
+		return l.activeSheet
+	}
+	return l.sheetName
+}
+
+func (l *sheetLoader) setActiveSheet(name string) {
+	l.sheetMu.Lock()
+	defer l.sheetMu.Unlock()
+	l.activeSheet = name
+}
+
+// a1Range — диапазон на листе в A1-нотации; имя листа всегда в кавычках,
+// чтобы пробелы и спецсимволы не ломали разбор
+func a1Range(sheet, rng string) string {
+	return "'" + strings.ReplaceAll(sheet, "'", "''") + "'!" + rng
+}
+
+// resolveHeaderRow — номер строки заголовков. При autoHeaderRow это последняя закреплённая
+// строка листа (frozenRowCount); если закреплённых строк нет или свойства прочитать не удалось —
+// настроенный headerRow.
+func (l *sheetLoader) resolveHeaderRow(ctx context.Context) int {
+	row := l.headerRow
+	if row < 1 {
+		row = 1
+	}
+	if !l.autoHeaderRow {
+		return row
+	}
+
+	var resp *sheets.Spreadsheet
+	err := withSheetsRetry(ctx, "чтение закреплённых строк", func() (err error) {
+		resp, err = l.service.Spreadsheets.Get(l.sheetID).
+			Fields("sheets.properties(title,gridProperties.frozenRowCount)").Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		log.Printf("⚠️ Не удалось прочитать закреплённые строки, заголовки в строке %d: %v", row, err)
+		return row
+	}
+	name := l.sheet()
+	for _, sh := range resp.Sheets {
+		if sh.Properties == nil || sh.Properties.Title != name {
+			continue
+		}
+		if gp := sh.Properties.GridProperties; gp != nil && gp.FrozenRowCount > 0 {
+			frozen := int(gp.FrozenRowCount)
+			if frozen >= dataLastRow {
+				log.Printf("⚠️ Закреплено %d строк — больше диапазона данных, заголовки в строке %d", frozen, row)
+				return row
+			}
+			return frozen
+		}
+	}
+	return row
+}
+
+// rereadCell — заново читает одну ячейку текущего листа (RETRY_PARSE_CELL)
+func (l *sheetLoader) rereadCell(ctx context.Context, col, row int) (string, error) {
+	var resp *sheets.ValueRange
+	rng := a1Range(l.sheet(), fmt.Sprintf("%s%d", columnLetter(col), row))
+	err := withSheetsRetry(ctx, "повторное чтение ячейки", func() (err error) {
+		resp, err = l.service.Spreadsheets.Values.Get(l.sheetID, rng).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Values) == 0 || len(resp.Values[0]) == 0 {
+		return "", nil
+	}
+	return cellToString(resp.Values[0][0]), nil
+}
+
+// readHeaderRow — читает строку заголовков с номером row
+func (l *sheetLoader) readHeaderRow(ctx context.Context, row int) (*sheets.ValueRange, error) {
+	headerRange := a1Range(l.sheet(), fmt.Sprintf("%d:%d", row, row))
+	var headerResp *sheets.ValueRange
+	err := withSheetsRetry(ctx, "чтение заголовков", func() (err error) {
+		headerResp, err = l.service.Spreadsheets.Values.Get(l.sheetID, headerRange).Context(ctx).Do()
+		return err
+	})
+	return headerResp, err
+}
+
+// isSheetNotFound — Sheets не смог разобрать диапазон: обычно лист переименован или удалён
+func isSheetNotFound(err error) bool {
+	var gerr *googleapi.Error
+	return errors.As(err, &gerr) && gerr.Code == http.StatusBadRequest &&
+		strings.Contains(gerr.Message, "Unable to parse range")
+}
+
+// fallbackSheetName — подбирает лист вместо отсутствующего: FALLBACK_SHEET_NAME, если он есть
+// в таблице, или первый лист при SHEET_FALLBACK_FIRST. В любом случае пишет в лог
+// список доступных листов, чтобы было понятно, что настроить.
+func (l *sheetLoader) fallbackSheetName(ctx context.Context) (string, bool) {
+	var resp *sheets.Spreadsheet
+	err := withSheetsRetry(ctx, "чтение списка листов", func() (err error) {
+		resp, err = l.service.Spreadsheets.Get(l.sheetID).Fields("sheets.properties.title").Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		log.Printf("❌ Лист %q не найден, список листов получить не удалось: %v", l.sheet(), err)
+		return "", false
+	}
+
+	var titles []string
+	for _, sh := range resp.Sheets {
+		if sh.Properties != nil {
+			titles = append(titles, sh.Properties.Title)
+		}
+	}
+	available := strings.Join(titles, ", ")
+
+	if l.fallbackSheet != "" {
+		for _, t := range titles {
+			if t == l.fallbackSheet {
+				log.Printf("⚠️ Лист %q не найден (доступны: %s), переключаемся на FALLBACK_SHEET_NAME=%q", l.sheet(), available, t)
+				return t, true
+			}
+		}
+	}
+	if l.fallbackFirst && len(titles) > 0 {
+		log.Printf("⚠️ Лист %q не найден (доступны: %s), переключаемся на первый лист %q", l.sheet(), available, titles[0])
+		return titles[0], true
+	}
+	log.Printf("❌ Лист %q не найден. Доступные листы: %s. Укажите SHEET_NAME или FALLBACK_SHEET_NAME", l.sheet(), available)
+	return "", false
+}
+
+// readDataRows — читает строки данных и возвращает их вместе с номером первой строки.
+// Если задан metadataKey и в таблице есть помеченный им диапазон, читаем его;
+// иначе — диапазон по умолчанию с firstRow. override (A1 без имени листа) заменяет и то и другое.
+func (l *sheetLoader) readDataRows(ctx context.Context, override string, firstRow int) ([][]interface{}, int, error) {
+	if override != "" {
+		_, startRow, _ := parseA1Start(override)
+		var resp *sheets.ValueRange
+		err := withSheetsRetry(ctx, "чтение заданного диапазона", func() (err error) {
+			resp, err = l.service.Spreadsheets.Values.Get(l.sheetID, a1Range(l.sheet(), override)).Context(ctx).Do()
+			return err
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+		return resp.Values, startRow, nil
+	}
+
+	if l.metadataKey != "" {
+		rows, startRow, ok := l.readMetadataRows(ctx)
+		if ok {
+			return rows, startRow, nil
+		}
+	}
+
+	if l.pageRows > 0 {
+		rows, err := l.readPagedRows(ctx, firstRow)
+		return rows, firstRow, err
+	}
+
+	dataRange := a1Range(l.sheet(), fmt.Sprintf("%d:%d", firstRow, dataLastRow))
+	var dataResp *sheets.ValueRange
+	err := withSheetsRetry(ctx, "чтение данных", func() (err error) {
+		dataResp, err = l.service.Spreadsheets.Values.Get(l.sheetID, dataRange).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return dataResp.Values, firstRow, nil
+}
+
+// readMetadataRows — читает строки, помеченные developer metadata с ключом metadataKey.
+// Метка должна покрывать целые строки (dimension ROWS), заголовки по-прежнему берутся из строки заголовков.
+// ok=false означает «метки нет или она непригодна» — тогда работаем как обычно.
+func (l *sheetLoader) readMetadataRows(ctx context.Context) ([][]interface{}, int, bool) {
+	req := &sheets.BatchGetValuesByDataFilterRequest{
+		DataFilters: []*sheets.DataFilter{{
+			DeveloperMetadataLookup: &sheets.DeveloperMetadataLookup{MetadataKey: l.metadataKey},
+		}},
+	}
+	var resp *sheets.BatchGetValuesByDataFilterResponse
+	err := withSheetsRetry(ctx, "чтение диапазона по метаданным", func() (err error) {
+		resp, err = l.service.Spreadsheets.Values.BatchGetByDataFilter(l.sheetID, req).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		log.Printf("⚠️ Не удалось прочитать диапазон по метаданным %q: %v", l.metadataKey, err)
+		return nil, 0, false
+	}
+	if len(resp.ValueRanges) == 0 || resp.ValueRanges[0].ValueRange == nil {
+		log.Printf("⚠️ Метаданные %q не найдены, читаем диапазон по умолчанию", l.metadataKey)
+		return nil, 0, false
+	}
+
+	vr := resp.ValueRanges[0].ValueRange
+	col, row, ok := parseA1Start(vr.Range)
+	if !ok || col != 0 {
+		log.Printf("⚠️ Диапазон метаданных %q (%s) должен начинаться с колонки A, читаем диапазон по умолчанию", l.metadataKey, vr.Range)
+		return nil, 0, false
+	}
+	log.Printf("ℹ️ Читаем данные из диапазона метаданных %q: %s", l.metadataKey, vr.Range)
+	return vr.Values, row, true
+}
+
+// rangeOverrideRe — допустимый диапазон для параметра range: строки "5:50" или
+// ячейки "A5:Z50" текущего листа. Имена листов, именованные диапазоны и прочее запрещены.
+var rangeOverrideRe = regexp.MustCompile(`^([A-Z]{1,3})?[1-9][0-9]{0,6}:([A-Z]{1,3})?[1-9][0-9]{0,6}$`)
+
+// validRangeOverride — диапазон безопасен и начинается с колонки A (иначе индексы колонок
+// не совпадут с заголовками) не выше второй строки
+func validRangeOverride(rng string) bool {
+	if !rangeOverrideRe.MatchString(rng) {
+		return false
+	}
+	col, row, ok := parseA1Start(rng)
+	return ok && col == 0 && row >= dataFirstRow
+}
+
+// parseA1Start — колонка (от нуля) и строка начала диапазона в A1-нотации: "Лист!B5:D9" → 1, 5.
+// Диапазон из целых строк ("Лист!5:9") начинается с колонки A.
+func parseA1Start(rng string) (col, row int, ok bool) {
+	if i := strings.LastIndex(rng, "!"); i >= 0 {
+		rng = rng[i+1:]
+	}
+	if i := strings.Index(rng, ":"); i >= 0 {
+		rng = rng[:i]
+	}
+	letters := 0
+	for letters < len(rng) && rng[letters] >= 'A' && rng[letters] <= 'Z' {
+		col = col*26 + int(rng[letters]-'A'+1)
+		letters++
+	}
+	row, err := strconv.Atoi(rng[letters:])
+	if err != nil || row < 1 {
+		return 0, 0, false
+	}
+	if letters == 0 {
+		return 0, row, true
+	}
+	return col - 1, row, true
+}
+
+// readLinkHyperlinks — читает цели гиперссылок колонки Link через includeGridData.
+// Элемент i соответствует i-й строке данных; пустая строка — гиперссылки нет.
+// Ошибка чтения не критична: остаёмся на отображаемых значениях.
+func (l *sheetLoader) readLinkHyperlinks(ctx context.Context, linkIndex, startRow, count int) []string {
+	if count == 0 {
+		return nil
+	}
+	col := columnLetter(linkIndex)
+	linkRange := a1Range(l.sheet(), fmt.Sprintf("%s%d:%s%d", col, startRow, col, startRow+count-1))
+
+	var resp *sheets.Spreadsheet
+	err := withSheetsRetry(ctx, "чтение гиперссылок", func() (err error) {
+		resp, err = l.service.Spreadsheets.Get(l.sheetID).
+			Ranges(linkRange).
+			IncludeGridData(true).
+			Fields("sheets(data(rowData(values(hyperlink))))").
+			Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		log.Printf("⚠️ Не удалось прочитать гиперссылки, используем текст ячеек: %v", err)
+		return nil
+	}
+	if len(resp.Sheets) == 0 || len(resp.Sheets[0].Data) == 0 {
+		return nil
+	}
+
+	rowData := resp.Sheets[0].Data[0].RowData
+	links := make([]string, len(rowData))
+	for i, rd := range rowData {
+		if len(rd.Values) > 0 {
+			links[i] = rd.Values[0].Hyperlink
+		}
+	}
+	return links
+}
+
+// columnLetter — буквенное обозначение колонки по индексу от нуля (0 → A, 26 → AA)
+func columnLetter(idx int) string {
+	var b []byte
+	for idx >= 0 {
+		b = append([]byte{byte('A' + idx%26)}, b...)
+		idx = idx/26 - 1
+	}
+	return string(b)
+}
+
+// validURL — абсолютная ссылка http(s) с непустым хостом
+func validURL(s string) bool {
+	u, err := url.Parse(strings.TrimSpace(s))
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
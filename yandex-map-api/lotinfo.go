@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// lotInfoParser — разбирает ячейку Lot_info в LotInfo
+type lotInfoParser func(s string) (LotInfo, error)
+
+// lotInfoParsers — форматы Lot_info (LOT_INFO_FORMAT)
+var lotInfoParsers = map[string]lotInfoParser{
+	"json": parseLotInfoJSON,
+	"kv":   parseLotInfoKV,
+}
+
+// parseLotInfoJSON — {"point":{"lat":..,"lon":..},"lotName":"..","lotDescription":".."}
+func parseLotInfoJSON(s string) (LotInfo, error) {
+	var lot LotInfo
+	err := json.Unmarshal([]byte(s), &lot)
+	return lot, err
+}
+
+// parseLotInfoKV — старый формат "name=Foo; desc=Bar; lat=55.7; lon=37.6".
+// Неизвестные ключи игнорируются, отсутствующие остаются пустыми (без координат строка
+// будет пропущена как обычно). Пары без "=" и нечисловые координаты — ошибка.
+func parseLotInfoKV(s string) (LotInfo, error) {
+	var lot LotInfo
+	for _, part := range strings.Split(s, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return lot, fmt.Errorf("ожидается ключ=значение, получено %q", part)
+		}
+		key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+		switch key {
+		case "name", "lotname":
+			lot.LotName = value
+		case "desc", "description", "lotdescription":
+			lot.LotDescription = value
+		case "lat", "lon", "lng":
+			f, err := parseCoordNumber(value)
+			if err != nil {
+				return lot, fmt.Errorf("некорректное число %s=%q", key, value)
+			}
+			if key == "lat" {
+				lot.Point.Lat = f
+			} else {
+				lot.Point.Lon = f
+			}
+		}
+	}
+	return lot, nil
+}
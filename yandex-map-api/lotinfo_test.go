@@ -0,0 +1,53 @@
+package main
+
+import "testing"
+
+func TestParseLotInfoKV(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       string
+		want     LotInfo
+		wantErr  bool
+		lat, lon float64
+	}{
+		{name: "все ключи", in: "name=Foo; desc=Bar; lat=55.7; lon=37.6",
+			want: LotInfo{LotName: "Foo", LotDescription: "Bar"}, lat: 55.7, lon: 37.6},
+		{name: "синонимы ключей", in: "lotName=Foo;description=Bar;lat=1;lng=2",
+			want: LotInfo{LotName: "Foo", LotDescription: "Bar"}, lat: 1, lon: 2},
+		// Без координат строка не ошибочна: её пропустит обычная проверка координат
+		{name: "нет lat и lon", in: "name=Foo; desc=Bar", want: LotInfo{LotName: "Foo", LotDescription: "Bar"}},
+		{name: "нет lon", in: "name=Foo; lat=55.7", want: LotInfo{LotName: "Foo"}, lat: 55.7},
+		{name: "неизвестные ключи", in: "name=Foo; color=red; lat=1; lon=2; area=12",
+			want: LotInfo{LotName: "Foo"}, lat: 1, lon: 2},
+		// Повторный ключ перезаписывает прежнее значение
+		{name: "повторные ключи", in: "name=Старое; name=Новое; lat=1; lat=3; lon=2",
+			want: LotInfo{LotName: "Новое"}, lat: 3, lon: 2},
+		{name: "пробелы и регистр", in: "  NAME = Foo Bar ;  Lat= 55.7 ;lon =37.6;; ",
+			want: LotInfo{LotName: "Foo Bar"}, lat: 55.7, lon: 37.6},
+		{name: "значение с =", in: "name=a=b; lat=1; lon=2", want: LotInfo{LotName: "a=b"}, lat: 1, lon: 2},
+		{name: "пустая ячейка", in: "", want: LotInfo{}},
+		{name: "пара без =", in: "name=Foo; 55.7", wantErr: true},
+		{name: "нечисловая координата", in: "name=Foo; lat=север; lon=2", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLotInfoKV(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("%q: ожидалась ошибка, получено %+v", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%q: %v", tt.in, err)
+			}
+			if got.LotName != tt.want.LotName || got.LotDescription != tt.want.LotDescription {
+				t.Errorf("%q: название %q, описание %q; ожидалось %q, %q",
+					tt.in, got.LotName, got.LotDescription, tt.want.LotName, tt.want.LotDescription)
+			}
+			if got.Point.Lat != tt.lat || got.Point.Lon != tt.lon {
+				t.Errorf("%q: координаты %v, %v; ожидалось %v, %v", tt.in, got.Point.Lat, got.Point.Lon, tt.lat, tt.lon)
+			}
+		})
+	}
+}
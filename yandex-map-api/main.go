@@ -1,24 +1,59 @@
 package main
 
 import (
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"google.golang.org/api/drive/v3"
 	"google.golang.org/api/option"
 	"google.golang.org/api/sheets/v4"
 )
 
 type LotPoint struct {
+	ID             string  `json:"id,omitempty"` // идентификатор лота из колонки ID
 	Lat            float64 `json:"lat"`
 	Lon            float64 `json:"lon"`
 	LotName        string  `json:"lotName"`
 	LotDescription string  `json:"lotDescription"`
+	Title          string  `json:"title,omitempty"` // собирается по TITLE_TEMPLATE
 	Link           string  `json:"link"`
+	LinkText       string  `json:"linkText,omitempty"` // подпись ссылки в балуне (колонка Link_text)
+	Priority       int     `json:"priority"`
+	Category       string  `json:"category,omitempty"`
+	Subcategory    string  `json:"subcategory,omitempty"`
+	Region         string  `json:"region,omitempty"`
+	Status         string  `json:"status,omitempty"`
+	Weight         float64 `json:"weight,omitempty"` // вес точки для центра кластера (колонка Weight)
+	// Price и Currency — цена из колонки Price и код валюты (RUB, USD, EUR);
+	// пустая или неразборчивая цена — поля нет
+	Price       *float64 `json:"price,omitempty"`
+	Currency    string   `json:"currency,omitempty"`
+	Approximate bool     `json:"approximate,omitempty"` // координаты — центр региона, а не точное место
+	Count       int      `json:"count,omitempty"`       // сколько лотов объединено в точку (dedup=true)
+	ThumbURL    string   `json:"thumbUrl,omitempty"`
+	ImageURL    string   `json:"imageUrl,omitempty"`
+	Hash        string   `json:"hash,omitempty"` // контрольная сумма полей точки (hash=true)
+	// CreatedAt — когда лот добавлен (колонка Created_at)
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	// Extras — нераспознанные колонки, заголовок → значение (EXTRA_COLUMNS, DUPLICATE_HEADERS=suffix)
+	Extras map[string]string `json:"extras,omitempty"`
+	// Draft — черновик (колонка Draft); такие точки отдаются только с includeDrafts=true
+	Draft bool    `json:"draft,omitempty"`
+	Raw   *RawRow `json:"raw,omitempty"`
+}
+
+// RawRow — исходные значения строки таблицы (только для отладки, includeRaw=true)
+type RawRow struct {
+	LotInfo string        `json:"lotInfo"`
+	Row     []interface{} `json:"row"`
 }
 
 type LotInfo struct {
@@ -26,8 +61,9 @@ type LotInfo struct {
 		Lat float64 `json:"lat"`
 		Lon float64 `json:"lon"`
 	} `json:"point"`
-	LotName        string `json:"lotName"`
-	LotDescription string `json:"lotDescription"`
+	Coordinates    []float64 `json:"coordinates,omitempty"` // [lat, lon], если нет point
+	LotName        string    `json:"lotName"`
+	LotDescription string    `json:"lotDescription"`
 }
 
 // normalizeHeader — приводит заголовок к каноничному виду (регистронезависимо, пробелы)
@@ -47,143 +83,902 @@ func main() {
 		sheetName = "Sheet1"
 	}
 
-	if sheetID == "" || credentialsJSON == "" {
+	// DEBUG=true открывает отладочные параметры запросов (includeRaw и т.п.)
+	debugMode := os.Getenv("DEBUG") == "true"
+	// ENABLE_JSONP=true разрешает параметр callback для старых встраиваний
+	enableJSONP := os.Getenv("ENABLE_JSONP") == "true"
+	// MAX_POINTS — максимум точек в одном ответе (0 — без ограничения)
+	maxPoints := 0
+	if v := os.Getenv("MAX_POINTS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			log.Fatalf("❌ Некорректный MAX_POINTS: %q", v)
+		}
+		maxPoints = n
+	}
+	// ENABLED_FORMATS — какие значения format принимать (через запятую, по умолчанию все)
+	enabledFormats, err := parseEnabledFormats(os.Getenv("ENABLED_FORMATS"))
+	if err != nil {
+		log.Fatalf("❌ Некорректный ENABLED_FORMATS: %v", err)
+	}
+	// CSV_FLUSH_ROWS — через сколько строк CSV-выгрузка сбрасывается клиенту
+	csvFlushRows := 500
+	if v := os.Getenv("CSV_FLUSH_ROWS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			log.Fatalf("❌ Некорректный CSV_FLUSH_ROWS: %q", v)
+		}
+		csvFlushRows = n
+	}
+	// MAX_GROUPS — сколько групп отдавать при groupBy, мелкие сверх лимита схлопываются в "other"
+	maxGroups := 100
+	if v := os.Getenv("MAX_GROUPS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			log.Fatalf("❌ Некорректный MAX_GROUPS: %q", v)
+		}
+		maxGroups = n
+	}
+
+	// GVIZ_MODE=true — читать опубликованную таблицу через gviz/tq, без сервисного аккаунта
+	gvizMode := os.Getenv("GVIZ_MODE") == "true"
+
+	if sheetID == "" || (credentialsJSON == "" && !gvizMode) {
 		log.Fatal("❌ Требуются GOOGLE_SHEET_ID и GOOGLE_CREDENTIALS в .env")
 	}
 
-	ctx := context.Background()
-	sheetsService, err := sheets.NewService(ctx, option.WithCredentialsJSON([]byte(credentialsJSON)))
+	var sheetsService *sheets.Service
+	if gvizMode {
+		log.Println("ℹ️ GVIZ_MODE: читаем опубликованную таблицу без сервисного аккаунта")
+	} else {
+		if err := validateCredentials(credentialsJSON); err != nil {
+			log.Fatalf("❌ GOOGLE_CREDENTIALS не является ключом сервисного аккаунта: %v", err)
+		}
+		var err error
+		sheetsService, err = sheets.NewService(context.Background(), option.WithCredentialsJSON([]byte(credentialsJSON)))
+		if err != nil {
+			log.Fatalf("❌ Ошибка создания Google Sheets клиента: %v", err)
+		}
+	}
+
+	// CACHE_TTL — как долго отдаём точки без повторного чтения таблицы (0 — читать каждый раз)
+	cacheTTL := 30 * time.Second
+	if v := os.Getenv("CACHE_TTL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			log.Fatalf("❌ Некорректный CACHE_TTL: %q", v)
+		}
+		cacheTTL = d
+	}
+
+	// MIN_REFRESH_INTERVAL — не читать таблицу чаще этого, даже если CACHE_TTL меньше
+	// или обновление запрашивают чаще (предохранитель для квоты Sheets)
+	var minRefresh time.Duration
+	if v := os.Getenv("MIN_REFRESH_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			log.Fatalf("❌ Некорректный MIN_REFRESH_INTERVAL: %q", v)
+		}
+		minRefresh = d
+	}
+
+	// SHEETS_ERROR_STATUS — какой HTTP-статус отдавать на ошибку Google Sheets с данным кодом,
+	// например "429=429,403=500" (по умолчанию 400→500, 403→502, 404→500, 429→503, прочие — 502)
+	if err := parseSheetsErrorStatuses(os.Getenv("SHEETS_ERROR_STATUS")); err != nil {
+		log.Fatalf("❌ Некорректный SHEETS_ERROR_STATUS: %v", err)
+	}
+
+	// DEFAULTS — значения для пустых ячеек, JSON: {"lotName": "Без названия"}
+	defaults, err := parseDefaults(os.Getenv("DEFAULTS"))
+	if err != nil {
+		log.Fatalf("❌ Некорректный DEFAULTS: %v", err)
+	}
+
+	loader := &sheetLoader{
+		service:   sheetsService,
+		sheetID:   sheetID,
+		sheetName: sheetName,
+		// LINK_READ_HYPERLINKS=true — восстанавливать URL из =HYPERLINK("url","текст")
+		readHyperlinks: os.Getenv("LINK_READ_HYPERLINKS") == "true",
+		defaults:       defaults,
+		// DATA_METADATA_KEY — брать строки данных из диапазона, помеченного developer metadata
+		metadataKey: os.Getenv("DATA_METADATA_KEY"),
+		// FALLBACK_SHEET_NAME / SHEET_FALLBACK_FIRST=true — куда переключиться, если SHEET_NAME не найден
+		fallbackSheet: os.Getenv("FALLBACK_SHEET_NAME"),
+		fallbackFirst: os.Getenv("SHEET_FALLBACK_FIRST") == "true",
+		// APPROXIMATE_PLACEMENT=true — лоты без координат ставить в центр региона из колонки Region
+		approximatePlacement: os.Getenv("APPROXIMATE_PLACEMENT") == "true",
+		// AUTO_HEADER_ROW=true — заголовки в последней закреплённой строке листа
+		autoHeaderRow: os.Getenv("AUTO_HEADER_ROW") == "true",
+		headerRow:     1,
+		sheetState:    &sheetState{},
+	}
+	// EXTRA_COLUMNS=true — отдавать нераспознанные колонки в поле extras.
+	// DUPLICATE_HEADERS — повторяющиеся заголовки: pick (по умолчанию, предупредить и взять
+	// последнюю колонку) или suffix (все колонки в extras: note, note_2; включает EXTRA_COLUMNS)
+	loader.extraColumns = os.Getenv("EXTRA_COLUMNS") == "true"
+	loader.duplicateHeaders = duplicateHeadersPick
+	if v := os.Getenv("DUPLICATE_HEADERS"); v != "" {
+		if v != duplicateHeadersPick && v != duplicateHeadersSuffix {
+			log.Fatalf("❌ Некорректный DUPLICATE_HEADERS: %q (допустимо: pick, suffix)", v)
+		}
+		loader.duplicateHeaders = v
+	}
+	// RETRY_PARSE_CELL=true — если Lot_info не разбирается, перечитать эту ячейку один раз
+	// перед тем как пропустить строку (на случай обрезанного ответа Sheets)
+	loader.retryParseCell = os.Getenv("RETRY_PARSE_CELL") == "true"
+	// AUTO_FIX_COORD_ORDER=true — исправлять перепутанные широту и долготу: только когда
+	// широта вне диапазона ±90, а после обмена координаты допустимы
+	loader.autoFixCoordOrder = os.Getenv("AUTO_FIX_COORD_ORDER") == "true"
+	// PRICE_DEFAULT_CURRENCY — код валюты для цен без ₽, руб, $ и т. п. (по умолчанию RUB;
+	// none — оставлять валюту пустой)
+	loader.priceCurrency = defaultPriceCurrency
+	if v := strings.TrimSpace(os.Getenv("PRICE_DEFAULT_CURRENCY")); v != "" {
+		loader.priceCurrency = strings.ToUpper(v)
+		if v == "none" {
+			loader.priceCurrency = ""
+		}
+	}
+	// COORD_SOURCE_PRIORITY — откуда брать координаты, если их в строке несколько:
+	// источники через запятую по убыванию приоритета (point, array, columns, string, region)
+	if v := os.Getenv("COORD_SOURCE_PRIORITY"); v != "" {
+		order, err := parseCoordPriority(v)
+		if err != nil {
+			log.Fatalf("❌ Некорректный COORD_SOURCE_PRIORITY: %v", err)
+		}
+		loader.coordPriority = order
+	}
+	// GRID_DATA_MAX_ROWS — листы не больше стольких строк читать одним запросом Spreadsheets.Get
+	// с includeGridData вместо двух-трёх Values.Get (см. grid.go). Ответ тяжелее, поэтому только
+	// для небольших таблиц; 0 (по умолчанию) — всегда обычное чтение
+	if v := os.Getenv("GRID_DATA_MAX_ROWS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			log.Fatalf("❌ Некорректный GRID_DATA_MAX_ROWS: %q", v)
+		}
+		loader.gridDataMaxRows = n
+	}
+	// HEADER_ROW — номер строки заголовков (по умолчанию 1); при AUTO_HEADER_ROW —
+	// запасной вариант для листов без закреплённых строк
+	if v := os.Getenv("HEADER_ROW"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n >= dataLastRow {
+			log.Fatalf("❌ Некорректный HEADER_ROW: %q", v)
+		}
+		loader.headerRow = n
+	}
+	// SNAP_GRID — шаг сетки в градусах (например 0.01): координаты всех точек переносятся
+	// в центры ячеек, чтобы нельзя было определить точное место лота
+	if v := os.Getenv("SNAP_GRID"); v != "" {
+		step, err := strconv.ParseFloat(v, 64)
+		if err != nil || step <= 0 || step > 10 {
+			log.Fatalf("❌ Некорректный SNAP_GRID: %q", v)
+		}
+		loader.snapGrid = step
+	}
+	// TITLE_TEMPLATE — собрать поле title из названия и описания, например "{name} — {desc}";
+	// TITLE_DESC_MAX — до скольких символов обрезать описание в title
+	if tmpl := os.Getenv("TITLE_TEMPLATE"); tmpl != "" {
+		descMax := 80
+		if v := os.Getenv("TITLE_DESC_MAX"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				log.Fatalf("❌ Некорректный TITLE_DESC_MAX: %q", v)
+			}
+			descMax = n
+		}
+		loader.title = &titleTemplate{tmpl: tmpl, descMax: descMax}
+	}
+	// LOT_INFO_FORMAT — формат ячейки Lot_info: json (по умолчанию) или kv ("name=..; desc=..; lat=..; lon=..")
+	if v := os.Getenv("LOT_INFO_FORMAT"); v != "" {
+		parse, ok := lotInfoParsers[v]
+		if !ok {
+			log.Fatalf("❌ Некорректный LOT_INFO_FORMAT: %q (допустимо: json, kv)", v)
+		}
+		loader.parseLotInfo = parse
+	}
+	// SHEET_PAGE_ROWS — читать большие листы страницами по стольку строк (до конца листа; если
+	// размер листа не прочитался — до первой пустой страницы, и строки после пустого блока
+	// такой длины теряются); PREFETCH_PAGES — сколько следующих страниц запрашивать параллельно
+	// (по умолчанию 1)
+	if v := os.Getenv("SHEET_PAGE_ROWS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			log.Fatalf("❌ Некорректный SHEET_PAGE_ROWS: %q", v)
+		}
+		loader.pageRows = n
+	}
+	loader.prefetchPages = 1
+	if v := os.Getenv("PREFETCH_PAGES"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > 16 {
+			log.Fatalf("❌ Некорректный PREFETCH_PAGES: %q (0–16)", v)
+		}
+		loader.prefetchPages = n
+	}
+	if gvizMode {
+		loader.gviz = newGvizSource(sheetID, sheetName)
+		if loader.autoHeaderRow || loader.headerRow != 1 {
+			log.Printf("⚠️ AUTO_HEADER_ROW и HEADER_ROW не поддерживаются в режиме GVIZ_MODE и будут проигнорированы")
+		}
+	}
+	// FIELD_MAPPING — путь к JSON-файлу с заголовками колонок, например {"lotInfo": "Координаты"}.
+	// При запуске проверяем, что все заголовки есть в таблице; STRICT_STARTUP=true — иначе не стартуем.
+	if path := os.Getenv("FIELD_MAPPING"); path != "" {
+		mapping, err := parseFieldMapping(path)
+		if err != nil {
+			log.Fatalf("❌ Некорректный FIELD_MAPPING %s: %v", path, err)
+		}
+		loader.mapping = mapping
+
+		strict := os.Getenv("STRICT_STARTUP") == "true"
+		ctx, cancel := context.WithTimeout(context.Background(), warmupTimeout)
+		missing, err := loader.checkMapping(ctx)
+		cancel()
+		switch {
+		case err != nil && strict:
+			log.Fatalf("❌ Не удалось проверить FIELD_MAPPING: %v", err)
+		case err != nil:
+			log.Printf("⚠️ Не удалось проверить FIELD_MAPPING: %v", err)
+		case len(missing) > 0 && strict:
+			log.Fatalf("❌ В таблице нет колонок из FIELD_MAPPING: %s", strings.Join(missing, ", "))
+		case len(missing) > 0:
+			log.Printf("⚠️ В таблице нет колонок из FIELD_MAPPING, поля будут пустыми: %s", strings.Join(missing, ", "))
+		default:
+			log.Printf("✅ FIELD_MAPPING: все %d колонок найдены", len(mapping))
+		}
+	}
+	// PIPELINE_FILE — путь к JSON-файлу с шагами обработки точек после разбора таблицы
+	// (trim, round, snap, fuzz, filter, default; см. parsePipeline). Шаги выполняются по порядку
+	// после DEFAULTS, SNAP_GRID и TITLE_TEMPLATE.
+	if path := os.Getenv("PIPELINE_FILE"); path != "" {
+		p, err := parsePipeline(path)
+		if err != nil {
+			log.Fatalf("❌ Некорректный PIPELINE_FILE %s: %v", path, err)
+		}
+		loader.pipeline = p
+		log.Printf("ℹ️ Конвейер обработки: %s", strings.Join(p.names(), " → "))
+	}
+	// ACCESS_TIERS — какие поля видят владельцы API-ключей разных уровней (JSON, см. parseAccessTiers);
+	// ключ передаётся в X-API-Key или apiKey, запросы без ключа получают уровень public
+	access, err := parseAccessTiers(os.Getenv("ACCESS_TIERS"))
+	if err != nil {
+		log.Fatalf("❌ Некорректный ACCESS_TIERS: %v", err)
+	}
+	if access != nil {
+		log.Printf("ℹ️ Уровни доступа: %s", strings.Join(access.tierNames(), ", "))
+	}
+
+	// EXTRA_SPREADSHEET_IDS — ещё таблицы с такой же структурой и тем же листом (через запятую);
+	// точки всех таблиц объединяются. SOURCE_FETCH_CONCURRENCY — сколько таблиц читать одновременно.
+	load := loader.load
+	var sources *multiSource
+	if v := os.Getenv("EXTRA_SPREADSHEET_IDS"); v != "" {
+		sources = &multiSource{loaders: []*sheetLoader{loader}, concurrency: defaultSourceFetchConcurrency}
+		seen := map[string]bool{sheetID: true}
+		for _, id := range strings.Split(v, ",") {
+			id = strings.TrimSpace(id)
+			if id == "" || seen[id] {
+				continue
+			}
+			seen[id] = true
+			sources.loaders = append(sources.loaders, loader.forSpreadsheet(id))
+		}
+		if v := os.Getenv("SOURCE_FETCH_CONCURRENCY"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > 32 {
+				log.Fatalf("❌ Некорректный SOURCE_FETCH_CONCURRENCY: %q (1–32)", v)
+			}
+			sources.concurrency = n
+		}
+		load = sources.load
+		log.Printf("ℹ️ Таблиц: %d, читаем одновременно до %d", len(sources.loaders), sources.concurrency)
+	}
+
+	// REFRESH_DEDUP=false — считать новым поколением каждое чтение таблицы, даже без изменений
+	cache := newPointCache(cacheTTL, os.Getenv("REFRESH_DEDUP") != "false", load)
+	cache.minRefresh = minRefresh
+	// STARTUP_WAIT — сколько запрос ждёт уже идущей первой загрузки, прежде чем получить 503
+	// (с WARMUP=true трафик обычно приходит после /ready, это страховка для узкого окна старта)
+	if v := os.Getenv("STARTUP_WAIT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			log.Fatalf("❌ Некорректный STARTUP_WAIT: %q", v)
+		}
+		cache.startupWait = d
+	}
+	// STALE_THRESHOLD — через сколько без успешного чтения таблицы метрика data_stale становится 1
+	// (по умолчанию 3 × CACHE_TTL, не меньше минуты). Таблица читается по запросам: без трафика
+	// возраст растёт и при исправной таблице, поэтому порог — больше обычного перерыва между запросами.
+	if v := os.Getenv("STALE_THRESHOLD"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			log.Fatalf("❌ Некорректный STALE_THRESHOLD: %q", v)
+		}
+		cache.staleThreshold = d
+	}
+	// MIN_POINTS_THRESHOLD — не принимать чтение, где точек меньше стольких (и меньше, чем было);
+	// MIN_POINTS_FRACTION — или меньше такой доли прежнего числа (0.5 — вдвое меньше).
+	// Отклонённое чтение не заменяет прежние точки; принять его можно через POST /api/refresh?force=true
+	if v := os.Getenv("MIN_POINTS_THRESHOLD"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			log.Fatalf("❌ Некорректный MIN_POINTS_THRESHOLD: %q", v)
+		}
+		cache.minPoints = n
+	}
+	if v := os.Getenv("MIN_POINTS_FRACTION"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 || f > 1 {
+			log.Fatalf("❌ Некорректный MIN_POINTS_FRACTION: %q (0–1)", v)
+		}
+		cache.minFraction = f
+	}
+	// REFRESH_MODE — что делать с запросом, пришедшим, когда данные устарели: stale (по умолчанию) —
+	// сразу отдать прежние данные и перечитать таблицу в фоне, wait — дождаться обновления
+	switch v := os.Getenv("REFRESH_MODE"); v {
+	case "", "stale":
+	case "wait":
+		cache.serveStale = false
+	default:
+		log.Fatalf("❌ Некорректный REFRESH_MODE: %q (допустимо: wait, stale)", v)
+	}
+
+	// CURSOR_SECRET — ключ подписи курсоров постраничной выдачи (cursor=); без него — случайный
+	// до перезапуска, поэтому для нескольких экземпляров за балансировщиком его нужно задать
+	cursorSecret = []byte(os.Getenv("CURSOR_SECRET"))
+	if len(cursorSecret) == 0 {
+		cursorSecret = randomCursorSecret()
+	}
+
+	// FIELD_RENAME — другие имена полей точки в ответах, JSON: {"lotName": "name", "link": "url"}
+	renames, err := parseFieldRenames(os.Getenv("FIELD_RENAME"))
 	if err != nil {
-		log.Fatalf("❌ Ошибка создания Google Sheets клиента: %v", err)
+		log.Fatalf("❌ Некорректный FIELD_RENAME: %v", err)
 	}
+	fieldRenames = renames
+
+	// DEDUP_TOLERANCE_METERS — с dedup=true схлопывать и точки, различающиеся меньше чем
+	// на столько метров (правки в 5-м знаке и т. п.); запрос может задать свой dedupTolerance
+	if v := os.Getenv("DEDUP_TOLERANCE_METERS"); v != "" {
+		m, err := strconv.ParseFloat(v, 64)
+		if err != nil || m < 0 || m > maxDedupToleranceMeters {
+			log.Fatalf("❌ Некорректный DEDUP_TOLERANCE_METERS: %q (0–%d)", v, maxDedupToleranceMeters)
+		}
+		dedupToleranceMeters = m
+	}
+
+	// POINT_COUNT_HEADERS=false — не отдавать X-Point-Count и X-Total-Count в /api/points
+	countHeaders := os.Getenv("POINT_COUNT_HEADERS") != "false"
 
 	http.HandleFunc("/api/points", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET")
 		w.Header().Set("Content-Type", "application/json")
 
-		if r.Method != http.MethodGet {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
 			http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
 			return
 		}
 
-		// 1. Читаем первую строку — заголовки
-		headerRange := sheetName + "!1:1"
-		headerResp, err := sheetsService.Spreadsheets.Values.Get(sheetID, headerRange).Do()
+		// Уровень доступа по API-ключу: недоступные поля очищаются перед выдачей
+		tier, ok := access.tierFor(r)
+		if !ok {
+			writeUnknownKey(w)
+			return
+		}
+
+		// Необязательно: контрольная сумма каждой точки для поиска изменений на клиенте (hash=true)
+		withHash := r.URL.Query().Get("hash") == "true"
+
+		// Отбор точек: ids, dedup, thin, sort, limit/offset (см. parsePointFilters);
+		// ненайденные ID возвращаются в заголовке X-Missing-Ids
+		filters, err := parsePointFilters(r.URL.Query(), maxPoints)
 		if err != nil {
-			log.Printf("❌ Ошибка чтения заголовков: %v", err)
-			http.Error(w, "Ошибка чтения структуры таблицы", http.StatusInternalServerError)
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		var headers []string
-		if len(headerResp.Values) > 0 {
-			for _, cell := range headerResp.Values[0] {
-				if str, ok := cell.(string); ok {
-					headers = append(headers, str)
-				} else {
-					headers = append(headers, "")
-				}
+		// Группировка: groupBy=category|subcategory|region|status; несколько полей через запятую
+		// (groupBy=category,subcategory) — вложенное дерево групп
+		groupBy := r.URL.Query().Get("groupBy")
+		var groupFields []string
+		if groupBy != "" {
+			groupFields, err = parseGroupFields(groupBy)
+			if err != nil {
+				http.Error(w, "Некорректный параметр groupBy: "+err.Error()+" (допустимо: "+strings.Join(groupableFieldNames(), ", ")+")", http.StatusBadRequest)
+				return
 			}
 		}
 
-		// 2. Ищем индексы нужных колонок
-		var lotInfoIndex, linkIndex int = -1, -1
-		for i, h := range headers {
-			norm := normalizeHeader(h)
-			if norm == "lot_info" || norm == "lot info" {
-				lotInfoIndex = i
+		// Формат ответа: json (по умолчанию), msgpack, csv, binary, geojson, xlsx или gmaps (Google Maps) —
+		// из разрешённых ENABLED_FORMATS
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = "json"
+		}
+		if !enabledFormats[format] {
+			http.Error(w, "Некорректный параметр format (допустимо: "+formatList(enabledFormats)+")", http.StatusBadRequest)
+			return
+		}
+
+		// GeoJSON для инструментов с ограничением числа объектов: maxFeatures=N (помечается truncated)
+		var maxFeatures int
+		if v := r.URL.Query().Get("maxFeatures"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				http.Error(w, "Некорректный параметр maxFeatures", http.StatusBadRequest)
+				return
 			}
-			if norm == "link" {
-				linkIndex = i
+			maxFeatures = n
+		}
+
+		// Локаль CSV: разделители дробей и полей (locale=ru — для русского Excel); на JSON не влияет
+		csvLoc := csvLocales["en"]
+		if v := r.URL.Query().Get("locale"); v != "" {
+			loc, ok := csvLocales[strings.ToLower(v)]
+			if !ok {
+				http.Error(w, "Некорректный параметр locale", http.StatusBadRequest)
+				return
 			}
+			csvLoc = loc
 		}
 
-		if lotInfoIndex == -1 {
-			log.Println("❌ Колонка 'Lot_info' не найдена в заголовках")
-			http.Error(w, "Колонка 'Lot_info' не найдена", http.StatusBadRequest)
-			return
+		// JSONP для старых встраиваний через <script>
+		callback := r.URL.Query().Get("callback")
+		if callback != "" {
+			if !enableJSONP {
+				http.Error(w, "JSONP отключён", http.StatusBadRequest)
+				return
+			}
+			if !validJSONPCallback(callback) {
+				http.Error(w, "Некорректное имя callback", http.StatusBadRequest)
+				return
+			}
 		}
-		if linkIndex == -1 {
-			log.Println("❌ Колонка 'Link' не найдена в заголовках")
-			http.Error(w, "Колонка 'Link' не найдена", http.StatusBadRequest)
-			return
+
+		// Необязательно: ответ-обёртка {"data": [...], "meta": {...}} со сведениями о точках
+		// и применённых преобразованиях (envelope=true); по умолчанию — просто массив
+		envelope := r.URL.Query().Get("envelope") == "true"
+
+		// Необязательно: только перечисленные поля точки (fields=lotName,link), остальные очищаются;
+		// координаты остаются всегда. Сужает набор полей уровня доступа, но не расширяет его.
+		var fieldSet map[string]bool
+		if v := r.URL.Query().Get("fields"); v != "" {
+			fieldSet, err = parseFieldSet(v)
+			if err != nil {
+				http.Error(w, "Некорректный параметр fields: "+err.Error(), http.StatusBadRequest)
+				return
+			}
 		}
 
-		// 3. Читаем все данные (начиная со 2-й строки)
-		dataRange := sheetName + "!2:10000" // можно увеличить при необходимости
-		dataResp, err := sheetsService.Spreadsheets.Values.Get(sheetID, dataRange).Do()
-		if err != nil {
-			log.Printf("❌ Ошибка чтения данных: %v", err)
-			http.Error(w, "Ошибка чтения данных", http.StatusInternalServerError)
+		// Сочетание формата с groupBy, envelope, callback, maxFeatures и fields — см. formatOptions
+		var options []string
+		for _, o := range []struct {
+			name string
+			set  bool
+		}{{optGroupBy, groupBy != ""}, {optEnvelope, envelope}, {optCallback, callback != ""},
+			{optMaxFeatures, maxFeatures > 0}, {optFields, fieldSet != nil}} {
+			if o.set {
+				options = append(options, o.name)
+			}
+		}
+		if err := checkFormatOptions(format, options); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		var points []LotPoint
+		// Необязательно: черновики для предпросмотра — только уровням доступа с "drafts": true
+		includeDrafts := r.URL.Query().Get("includeDrafts") == "true"
+		if includeDrafts && !tier.seesDrafts() {
+			http.Error(w, "Параметр includeDrafts доступен только для ключей с доступом к черновикам", http.StatusForbidden)
+			return
+		}
 
-		for rowIndex, row := range dataResp.Values {
-			// Пропускаем пустые строки
-			if len(row) == 0 {
-				continue
-			}
+		// Необязательно: исходные значения строк (только в режиме отладки)
+		includeRaw := r.URL.Query().Get("includeRaw") == "true"
+		if includeRaw && !debugMode {
+			http.Error(w, "Параметр includeRaw доступен только в режиме отладки", http.StatusForbidden)
+			return
+		}
 
-			// Получаем значение Lot_info
-			var lotInfoStr string
-			if lotInfoIndex < len(row) {
-				if s, ok := row[lotInfoIndex].(string); ok {
-					lotInfoStr = s
-				}
+		// Отладка: явный A1-диапазон строк данных вместо вычисленного (в обход кэша)
+		rangeOverride := r.URL.Query().Get("range")
+		if rangeOverride != "" {
+			if !debugMode {
+				http.Error(w, "Параметр range доступен только в режиме отладки", http.StatusForbidden)
+				return
 			}
-			if lotInfoStr == "" {
-				continue // пропускаем, если нет данных
+			if gvizMode || !validRangeOverride(rangeOverride) {
+				http.Error(w, "Некорректный параметр range (ожидается, например, 2:50 или A2:Z50)", http.StatusBadRequest)
+				return
 			}
+		}
 
-			// Получаем значение Link
-			var linkStr string
-			if linkIndex < len(row) {
-				if s, ok := row[linkIndex].(string); ok {
-					linkStr = s
-				}
-			}
+		var data *dataset
+		if rangeOverride != "" {
+			data, err = loader.loadRange(r.Context(), rangeOverride)
+		} else {
+			data, err = cache.get(r.Context())
+		}
+		if err != nil {
+			writeLoadError(w, err)
+			return
+		}
 
-			// Парсим JSON
-			var lot LotInfo
-			if err := json.Unmarshal([]byte(lotInfoStr), &lot); err != nil {
-				log.Printf("⚠️ Ошибка парсинга Lot_info в строке %d: %v", rowIndex+2, err)
-				continue
+		// Кэш общий для всех запросов, поэтому дальше работаем с копией
+		points := make([]LotPoint, len(data.Points), len(data.Points)+len(data.Drafts))
+		copy(points, data.Points)
+		if includeDrafts {
+			points = append(points, data.Drafts...)
+		}
+		if !includeRaw {
+			for i := range points {
+				points[i].Raw = nil
 			}
+		}
+		tier.project(points)
 
-			// Пропускаем, если нет координат
-			if lot.Point.Lat == 0 && lot.Point.Lon == 0 {
-				continue
+		if filters.frame != nil && !data.HasCreatedAt {
+			http.Error(w, "Параметр frame требует колонку Created_at в таблице", http.StatusBadRequest)
+			return
+		}
+		// Без доступа к createdAt дата у всех точек очищена — кадр был бы молча пустым
+		if filters.frame != nil && !tier.allows("createdAt") {
+			http.Error(w, "Параметр frame недоступен для этого ключа: нет доступа к полю createdAt", http.StatusBadRequest)
+			return
+		}
+		if filters.frame != nil {
+			// Границы шкалы кадров — по всем точкам, а не только по кадру
+			if first, last, ok := createdAtRange(points); ok {
+				w.Header().Set("X-Frame-First", first.Format(time.RFC3339))
+				w.Header().Set("X-Frame-Last", last.Format(time.RFC3339))
 			}
+		}
+
+		res := filters.apply(points, nil)
+		points, total := res.points, res.total
+		// fields= сужает только ответ: фильтры, поиск и сортировка видят все доступные поля
+		if fieldSet != nil {
+			(&accessTier{fields: fieldSet}).project(points)
+		}
+		if len(res.missingIDs) > 0 {
+			w.Header().Set("X-Missing-Ids", strings.Join(res.missingIDs, ","))
+		}
+		if res.nextOffset > 0 {
+			w.Header().Set("X-Has-More", "true")
+			w.Header().Set("X-Next-Offset", strconv.Itoa(res.nextOffset))
+			w.Header().Set("X-Next-Cursor", res.nextCursor)
+		}
 
-			points = append(points, LotPoint{
-				Lat:            lot.Point.Lat,
-				Lon:            lot.Point.Lon,
-				LotName:        lot.LotName,
-				LotDescription: lot.LotDescription,
-				Link:           linkStr,
-			})
+		// Число точек в заголовках — для HEAD-запросов и виджетов, которым не нужно тело:
+		// X-Total-Count — до разбиения на страницы, X-Point-Count — в этом ответе
+		if countHeaders {
+			w.Header().Set("X-Total-Count", strconv.Itoa(total))
+			w.Header().Set("X-Point-Count", strconv.Itoa(len(points)))
 		}
 
-		log.Printf("✅ Найдено %d точек для отображения", len(points))
-		if err := json.NewEncoder(w).Encode(points); err != nil {
+		if withHash {
+			for i := range points {
+				points[i].Hash = pointHash(points[i])
+			}
+		}
+
+		log.Printf("✅ Отдаём %d точек для отображения", len(points))
+		var out interface{} = points
+		if groupBy != "" {
+			out = groupPointsTree(points, groupFields, maxGroups)
+		}
+		if envelope {
+			out = pointsEnvelope{Data: out, Meta: envelopeMeta{
+				Total:      total,
+				Count:      len(points),
+				NextCursor: res.nextCursor,
+				Transforms: requestTransforms(loader.transforms(), tier, filters),
+			}}
+		}
+		if format == "csv" {
+			if err := writeCSV(w, points, csvLoc, csvFlushRows); err != nil {
+				log.Printf("❌ Выгрузка CSV прервана: %v", err)
+			}
+			return
+		}
+		if format == "xlsx" {
+			if err := writeXLSX(w, points); err != nil {
+				log.Printf("❌ Ошибка выгрузки XLSX: %v", err)
+				http.Error(w, "Ошибка сериализации", http.StatusInternalServerError)
+			}
+			return
+		}
+		if format == "geojson" {
+			if err := writeGeoJSON(w, points, maxFeatures); err != nil {
+				log.Printf("❌ Ошибка отправки GeoJSON: %v", err)
+			}
+			return
+		}
+		if format == "gmaps" {
+			if err := writeGMaps(w, points); err != nil {
+				log.Printf("❌ Ошибка отправки JSON: %v", err)
+			}
+			return
+		}
+		if format == "binary" {
+			if err := writeBinary(w, points); err != nil {
+				log.Printf("❌ Ошибка отправки бинарного массива: %v", err)
+			}
+			return
+		}
+		if format == "msgpack" {
+			if err := writeMsgpack(w, out); err != nil {
+				log.Printf("❌ Ошибка отправки MessagePack: %v", err)
+			}
+			return
+		}
+		if callback != "" {
+			if err := writeJSONP(w, callback, out); err != nil {
+				log.Printf("❌ Ошибка отправки JSONP: %v", err)
+			}
+			return
+		}
+		if err := json.NewEncoder(w).Encode(out); err != nil {
 			log.Printf("❌ Ошибка отправки JSON: %v", err)
 			http.Error(w, "Ошибка сериализации", http.StatusInternalServerError)
 		}
 	})
 
-	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+	// Отладка: строки, которые пропущены или заполнены не полностью, — список для правки таблицы
+	http.HandleFunc("/api/points/incomplete", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+
+		if r.Method != http.MethodGet {
+			http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+			return
+		}
+		if !debugMode {
+			http.Error(w, "Доступно только в режиме отладки", http.StatusForbidden)
+			return
+		}
+
+		data, err := cache.get(r.Context())
+		if err != nil {
+			writeLoadError(w, err)
+			return
+		}
+		issues := data.Issues
+		if issues == nil {
+			issues = []rowIssue{}
+		}
+
+		// format=csv — список для редакторов; locale как у /api/points (locale=ru — для русского Excel)
+		switch r.URL.Query().Get("format") {
+		case "", "json":
+		case "csv":
+			loc := csvLocales["en"]
+			if v := r.URL.Query().Get("locale"); v != "" {
+				l, ok := csvLocales[strings.ToLower(v)]
+				if !ok {
+					http.Error(w, "Некорректный параметр locale", http.StatusBadRequest)
+					return
+				}
+				loc = l
+			}
+			if err := writeIssuesCSV(w, issues, loc); err != nil {
+				log.Printf("❌ Выгрузка CSV прервана: %v", err)
+			}
+			return
+		default:
+			http.Error(w, "Некорректный параметр format (допустимо: json, csv)", http.StatusBadRequest)
+			return
+		}
+		if err := json.NewEncoder(w).Encode(issues); err != nil {
+			log.Printf("❌ Ошибка отправки JSON: %v", err)
+		}
 	})
 
+	http.HandleFunc("/api/points/explain", explainHandler(cache, access, maxPoints))
+	// MAP_YANDEX_API_KEY, MAP_CENTER, MAP_ZOOM, MAP_LAYERS — настройки карты для фронтенда (/api/config)
+	frontend, err := parseFrontendConfig()
+	if err != nil {
+		log.Fatalf("❌ Некорректные настройки карты: %v", err)
+	}
+	http.HandleFunc("/api/config", configHandler(frontend))
+	// REFRESH_TOKEN — включает POST /api/refresh (немедленное перечитывание таблицы) с этим токеном
+	if token := os.Getenv("REFRESH_TOKEN"); token != "" {
+		http.HandleFunc("/api/refresh", refreshHandler(cache, token))
+	}
+	// REGIONS_FILE — GeoJSON с полигонами административных регионов для /api/points/by-region;
+	// REGIONS_NAME_PROPERTY — свойство с названием региона (по умолчанию name)
+	if path := os.Getenv("REGIONS_FILE"); path != "" {
+		nameProp := os.Getenv("REGIONS_NAME_PROPERTY")
+		if nameProp == "" {
+			nameProp = "name"
+		}
+		boundaries, err := loadBoundaries(path, nameProp)
+		if err != nil {
+			log.Fatalf("❌ Некорректный REGIONS_FILE %s: %v", path, err)
+		}
+		log.Printf("ℹ️ Загружено регионов для /api/points/by-region: %d", len(boundaries))
+		http.HandleFunc("/api/points/by-region", byRegionHandler(cache, boundaries))
+	}
+	http.HandleFunc("/api/points/near", nearHandler(cache, access))
+
+	// MAX_DENSITY_CELLS — наибольшее число ячеек cols×rows в /api/points/density
+	maxDensityCells := 10000
+	if v := os.Getenv("MAX_DENSITY_CELLS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			log.Fatalf("❌ Некорректный MAX_DENSITY_CELLS: %q", v)
+		}
+		maxDensityCells = n
+	}
+	http.HandleFunc("/api/tiles/", tilesHandler(cache, access))
+	http.HandleFunc("/api/facets/", facetsHandler(cache, access))
+	http.HandleFunc("/api/points/clusters", clustersHandler(cache, access))
+	http.HandleFunc("/api/points/density", densityHandler(cache, maxDensityCells))
+	// QUADTREE_THRESHOLD — сколько точек узел /api/points/quadtree отдаёт списком, а не числом
+	quadtreeThreshold := 50
+	if v := os.Getenv("QUADTREE_THRESHOLD"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxQuadtreeThreshold {
+			log.Fatalf("❌ Некорректный QUADTREE_THRESHOLD: %q (1–%d)", v, maxQuadtreeThreshold)
+		}
+		quadtreeThreshold = n
+	}
+	http.HandleFunc("/api/points/quadtree", quadtreeHandler(cache, access, quadtreeThreshold))
+
+	health := &healthChecks{cache: cache, sources: sources}
+	// Режим обслуживания: MAINTENANCE=true или существование файла MAINTENANCE_FILE.
+	// /health/ready отвечает 503, /health/live — только при MAINTENANCE_FAILS_LIVENESS=true
+	if os.Getenv("MAINTENANCE") == "true" || os.Getenv("MAINTENANCE_FILE") != "" {
+		health.maintenance = &maintenanceMode{
+			always:        os.Getenv("MAINTENANCE") == "true",
+			file:          os.Getenv("MAINTENANCE_FILE"),
+			failsLiveness: os.Getenv("MAINTENANCE_FAILS_LIVENESS") == "true",
+		}
+		if health.maintenance.active() {
+			log.Printf("⚠️ Режим обслуживания включён: /health/ready отвечает 503")
+		}
+	}
+	// LAST_EDITOR=true — показывать в /health/detail, кто последним правил таблицу.
+	// Нужен доступ сервисного аккаунта к Drive (область drive.metadata.readonly); без него поле просто не выводится.
+	if os.Getenv("LAST_EDITOR") == "true" {
+		if gvizMode {
+			log.Println("⚠️ LAST_EDITOR не поддерживается в режиме GVIZ_MODE")
+		} else {
+			driveService, err := drive.NewService(context.Background(),
+				option.WithCredentialsJSON([]byte(credentialsJSON)), option.WithScopes(drive.DriveMetadataReadonlyScope))
+			if err != nil {
+				log.Fatalf("❌ Ошибка создания Google Drive клиента: %v", err)
+			}
+			health.editor = &editorSource{service: driveService, fileID: sheetID}
+		}
+	}
+
+	// HEALTH_PATHS — пути проверки здоровья через запятую; у каждого есть подпути /detail, /ready, /live
+	healthPaths, err := parseHealthPaths(os.Getenv("HEALTH_PATHS"))
+	if err != nil {
+		log.Fatalf("❌ Некорректный HEALTH_PATHS: %v", err)
+	}
+	health.register(healthPaths)
+
+	http.HandleFunc("/", landingHandler(healthPaths))
+
+	// WARMUP=true — загрузить данные до того, как /health/ready пропустит трафик
+	if os.Getenv("WARMUP") == "true" {
+		go health.warmup(context.Background())
+	} else {
+		health.ready.Store(true)
+	}
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 
+	// REQUEST_TIMEOUT — общий таймаут запроса, ENDPOINT_TIMEOUTS — свои таймауты для отдельных маршрутов
+	timeouts := routeTimeouts{
+		def:    30 * time.Second,
+		routes: map[string]time.Duration{},
+	}
+	for _, p := range healthPaths {
+		for _, sub := range healthSubpaths {
+			timeouts.routes[p+sub] = 2 * time.Second
+		}
+	}
+	if v := os.Getenv("REQUEST_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			log.Fatalf("❌ Некорректный REQUEST_TIMEOUT: %q", v)
+		}
+		timeouts.def = d
+	}
+	routes, err := parseRouteTimeouts(os.Getenv("ENDPOINT_TIMEOUTS"))
+	if err != nil {
+		log.Fatalf("❌ Некорректный ENDPOINT_TIMEOUTS: %v", err)
+	}
+	for path, d := range routes {
+		timeouts.routes[path] = d
+	}
+
+	http.Handle("/metrics", metrics)
+
+	var handler http.Handler = http.DefaultServeMux
+
+	// GZIP=false — не сжимать ответы; GZIP_LEVEL — уровень сжатия 1–9
+	// (меньше — быстрее и дешевле по CPU, больше — меньше трафика)
+	if os.Getenv("GZIP") != "false" {
+		level := gzip.DefaultCompression
+		if v := os.Getenv("GZIP_LEVEL"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < gzip.BestSpeed || n > gzip.BestCompression {
+				log.Fatalf("❌ Некорректный GZIP_LEVEL: %q (1–9)", v)
+			}
+			level = n
+		}
+		handler = withGzip(handler, level)
+	}
+
+	// MAX_CONCURRENT_REQUESTS — сколько запросов к /api/ обрабатывать одновременно (0 — без ограничения);
+	// QUEUE_SIZE и QUEUE_MAX_WAIT — сколько запросов и как долго могут ждать своей очереди
+	if v := os.Getenv("MAX_CONCURRENT_REQUESTS"); v != "" && v != "0" {
+		maxConcurrent, err := strconv.Atoi(v)
+		if err != nil || maxConcurrent < 0 {
+			log.Fatalf("❌ Некорректный MAX_CONCURRENT_REQUESTS: %q", v)
+		}
+		queueSize := 100
+		if v := os.Getenv("QUEUE_SIZE"); v != "" {
+			if queueSize, err = strconv.Atoi(v); err != nil || queueSize < 0 {
+				log.Fatalf("❌ Некорректный QUEUE_SIZE: %q", v)
+			}
+		}
+		queueWait := 5 * time.Second
+		if v := os.Getenv("QUEUE_MAX_WAIT"); v != "" {
+			if queueWait, err = time.ParseDuration(v); err != nil || queueWait < 0 {
+				log.Fatalf("❌ Некорректный QUEUE_MAX_WAIT: %q", v)
+			}
+		}
+		handler = newRequestQueue(maxConcurrent, queueSize, queueWait).middleware(handler)
+		log.Printf("ℹ️ Очередь запросов: %d одновременно, до %d в ожидании по %v", maxConcurrent, queueSize, queueWait)
+	}
+
+	// CORS для всех маршрутов: CORS_ALLOWED_ORIGINS (через запятую, по умолчанию *),
+	// CORS_ALLOWED_METHODS, CORS_ALLOWED_HEADERS и CORS_MAX_AGE — для ответов на preflight,
+	// CORS_ALLOW_CREDENTIALS=true — запросы с cookie (только с явным списком источников)
+	cors := corsConfig{
+		origins: []string{"*"},
+		methods: "GET, HEAD, OPTIONS",
+		headers: "X-API-Key, Content-Type",
+		expose:  "X-Has-More, X-Next-Offset, X-Next-Cursor, X-Point-Count, X-Total-Count, X-Missing-Ids, X-Frame-First, X-Frame-Last, Retry-After",
+		maxAge:  10 * time.Minute,
+	}
+	if v := os.Getenv("CORS_ALLOWED_ORIGINS"); v != "" {
+		cors.origins = nil
+		for _, o := range strings.Split(v, ",") {
+			if o = strings.TrimSpace(o); o != "" {
+				cors.origins = append(cors.origins, o)
+			}
+		}
+	}
+	if v := os.Getenv("CORS_ALLOWED_METHODS"); v != "" {
+		cors.methods = v
+	}
+	if v := os.Getenv("CORS_ALLOWED_HEADERS"); v != "" {
+		cors.headers = v
+	}
+	if v := os.Getenv("CORS_MAX_AGE"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			log.Fatalf("❌ Некорректный CORS_MAX_AGE: %q", v)
+		}
+		cors.maxAge = d
+	}
+	cors.credentials = os.Getenv("CORS_ALLOW_CREDENTIALS") == "true"
+	if err := cors.validate(); err != nil {
+		log.Fatalf("❌ Некорректные настройки CORS: %v", err)
+	}
+	if cors.credentials {
+		log.Printf("ℹ️ CORS с учётными данными для источников: %s", strings.Join(cors.origins, ", "))
+	}
+	handler = withCORS(handler, cors)
+
 	log.Printf("✅ Сервер запущен на порту %s", port)
-	log.Fatal(http.ListenAndServe(":"+port, nil))
-}
\ No newline at end of file
+	log.Fatal(http.ListenAndServe(":"+port, withTimeouts(handler, timeouts)))
+}
@@ -0,0 +1,31 @@
+package main
+
+import "os"
+
+// maintenanceMode — режим обслуживания: /health/ready отвечает 503, чтобы балансировщик
+// вывел экземпляр из ротации. Запросы к API при этом обслуживаются как обычно — начатые
+// успевают завершиться.
+type maintenanceMode struct {
+	// always — режим включён постоянно (MAINTENANCE=true)
+	always bool
+	// file — режим включён, пока существует этот файл (MAINTENANCE_FILE): touch / rm без перезапуска
+	file string
+	// failsLiveness — в режиме обслуживания отвечать 503 и на /health/live, чтобы оркестратор
+	// перезапустил экземпляр (MAINTENANCE_FAILS_LIVENESS)
+	failsLiveness bool
+}
+
+// active — включён ли режим сейчас (nil — режим не настроен)
+func (m *maintenanceMode) active() bool {
+	if m == nil {
+		return false
+	}
+	if m.always {
+		return true
+	}
+	if m.file == "" {
+		return false
+	}
+	_, err := os.Stat(m.file)
+	return err == nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// fieldMapping — сопоставление логических полей заголовкам колонок: {"lotInfo": "Координаты"}.
+// Для перечисленных полей стандартные заголовки не используются.
+type fieldMapping map[string]string
+
+// parseFieldMapping — читает файл сопоставления (JSON-объект)
+func parseFieldMapping(path string) (fieldMapping, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m fieldMapping
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("ожидается JSON-объект: %w", err)
+	}
+	for field, header := range m {
+		if !isColumnField(field) {
+			return nil, fmt.Errorf("неизвестное поле %q", field)
+		}
+		if normalizeHeader(header) == "" {
+			return nil, fmt.Errorf("пустой заголовок для поля %q", field)
+		}
+	}
+	return m, nil
+}
+
+func isColumnField(field string) bool {
+	for _, spec := range columnSpecs {
+		if spec.field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// matches — нормализованный заголовок norm соответствует полю spec
+func (m fieldMapping) matches(spec columnSpec, norm string) bool {
+	if header, ok := m[spec.field]; ok {
+		return normalizeHeader(header) == norm
+	}
+	for _, alias := range spec.aliases {
+		if norm == alias {
+			return true
+		}
+	}
+	return false
+}
+
+// unmatched — поля сопоставления, заголовков которых нет среди headers ("поле → заголовок")
+func (m fieldMapping) unmatched(headers []string) []string {
+	present := make(map[string]bool, len(headers))
+	for _, h := range headers {
+		present[normalizeHeader(h)] = true
+	}
+	var missing []string
+	for field, header := range m {
+		if !present[normalizeHeader(header)] {
+			missing = append(missing, fmt.Sprintf("%s → %q", field, header))
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// checkMapping — читает заголовки один раз и возвращает поля сопоставления без колонки
+func (l *sheetLoader) checkMapping(ctx context.Context) ([]string, error) {
+	headers, _, err := l.readHeaders(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return l.mapping.unmatched(headers), nil
+}
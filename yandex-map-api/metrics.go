@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// metrics — общий реестр метрик, отдаётся на /metrics в текстовом формате Prometheus
+var metrics = &metricsRegistry{}
+
+// counter — монотонно растущий счётчик
+type counter struct {
+	bits atomic.Uint64 // float64 в виде битов, чтобы прибавлять и дробные значения
+}
+
+func (c *counter) Inc() { c.Add(1) }
+
+func (c *counter) Add(v float64) {
+	for {
+		old := c.bits.Load()
+		if c.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+func (c *counter) Value() float64 { return math.Float64frombits(c.bits.Load()) }
+
+type metricItem struct {
+	name, help, kind string
+	value            func() float64
+}
+
+type metricsRegistry struct {
+	mu    sync.Mutex
+	items []metricItem
+}
+
+// counter — регистрирует счётчик
+func (m *metricsRegistry) counter(name, help string) *counter {
+	c := &counter{}
+	m.register(metricItem{name, help, "counter", c.Value})
+	return c
+}
+
+// gauge — регистрирует показатель, значение которого вычисляется при каждом запросе /metrics
+func (m *metricsRegistry) gauge(name, help string, value func() float64) {
+	m.register(metricItem{name, help, "gauge", value})
+}
+
+func (m *metricsRegistry) register(item metricItem) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.items = append(m.items, item)
+}
+
+func (m *metricsRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	items := append([]metricItem(nil), m.items...)
+	m.mu.Unlock()
+	sort.Slice(items, func(i, j int) bool { return items[i].name < items[j].name })
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	for _, it := range items {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n%s %g\n", it.name, it.help, it.name, it.kind, it.name, it.value())
+	}
+}
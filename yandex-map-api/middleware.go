@@ -0,0 +1,178 @@
+package main
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// routeTimeouts — таймауты запросов по маршрутам; для остальных путей действует def
+type routeTimeouts struct {
+	def    time.Duration
+	routes map[string]time.Duration
+}
+
+// parseRouteTimeouts — разбирает ENDPOINT_TIMEOUTS: "/api/points=20s,/health=2s"
+func parseRouteTimeouts(s string) (map[string]time.Duration, error) {
+	routes := make(map[string]time.Duration)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		path, value, ok := strings.Cut(part, "=")
+		if !ok || !strings.HasPrefix(path, "/") {
+			return nil, fmt.Errorf("ожидается путь=длительность, получено %q", part)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("некорректная длительность для %s: %q", path, value)
+		}
+		routes[strings.TrimSpace(path)] = d
+	}
+	return routes, nil
+}
+
+// timeoutFor — таймаут для пути: точное совпадение маршрута, иначе значение по умолчанию
+func (t routeTimeouts) timeoutFor(path string) time.Duration {
+	if d, ok := t.routes[path]; ok {
+		return d
+	}
+	return t.def
+}
+
+// withTimeouts — ограничивает время обработки запроса таймаутом его маршрута.
+// Срок передаётся через контекст запроса, поэтому обращения к Sheets прерываются вместе с ним.
+func withTimeouts(next http.Handler, t routeTimeouts) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d := t.timeoutFor(r.URL.Path)
+		if d <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), d)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// gzipResponseWriter — ResponseWriter, сжимающий тело ответа
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gz          *gzip.Writer
+	wroteHeader bool
+}
+
+func (g *gzipResponseWriter) WriteHeader(status int) {
+	if !g.wroteHeader {
+		g.wroteHeader = true
+		g.Header().Del("Content-Length")
+		g.Header().Set("Content-Encoding", "gzip")
+	}
+	g.ResponseWriter.WriteHeader(status)
+}
+
+func (g *gzipResponseWriter) Write(b []byte) (int, error) {
+	if !g.wroteHeader {
+		g.WriteHeader(http.StatusOK)
+	}
+	return g.gz.Write(b)
+}
+
+// Flush — сбрасывает сжатые данные клиенту (нужно для потоковой выгрузки CSV)
+func (g *gzipResponseWriter) Flush() {
+	g.gz.Flush()
+	if f, ok := g.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// withGzip — сжимает ответы для клиентов, принимающих gzip, с уровнем level (см. compress/gzip)
+func withGzip(next http.Handler, level int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		gz, _ := gzip.NewWriterLevel(w, level) // уровень проверен при запуске
+		gw := &gzipResponseWriter{ResponseWriter: w, gz: gz}
+		defer func() {
+			if gw.wroteHeader {
+				gz.Close()
+			}
+		}()
+		next.ServeHTTP(gw, r)
+	})
+}
+
+// corsConfig — настройки CORS для всех маршрутов
+type corsConfig struct {
+	origins []string // "*" — любой источник
+	methods string
+	headers string
+	expose  string
+	maxAge  time.Duration
+	// credentials — Access-Control-Allow-Credentials: true; источник тогда всегда
+	// возвращается как есть, "*" с учётными данными браузер не примет
+	credentials bool
+}
+
+// validate — проверка сочетания настроек при запуске
+func (c corsConfig) validate() error {
+	if !c.credentials {
+		return nil
+	}
+	for _, o := range c.origins {
+		if o == "*" {
+			return fmt.Errorf("с CORS_ALLOW_CREDENTIALS нужно перечислить источники в CORS_ALLOWED_ORIGINS, \"*\" недопустим")
+		}
+	}
+	return nil
+}
+
+// allowOrigin — значение Access-Control-Allow-Origin для источника запроса ("" — не разрешён)
+func (c corsConfig) allowOrigin(origin string) string {
+	for _, o := range c.origins {
+		if o == "*" && !c.credentials {
+			return "*"
+		}
+		if origin != "" && strings.EqualFold(o, origin) {
+			return origin
+		}
+	}
+	return ""
+}
+
+// withCORS — CORS-заголовки для всех ответов и ответ на предварительные запросы OPTIONS,
+// чтобы обработчикам не нужно было заниматься этим самим
+func withCORS(next http.Handler, c corsConfig) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		allowed := c.allowOrigin(origin)
+		if allowed != "*" {
+			w.Header().Add("Vary", "Origin")
+		}
+		if allowed != "" {
+			w.Header().Set("Access-Control-Allow-Origin", allowed)
+			w.Header().Set("Access-Control-Expose-Headers", c.expose)
+			if c.credentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			if allowed != "" {
+				w.Header().Set("Access-Control-Allow-Methods", c.methods)
+				w.Header().Set("Access-Control-Allow-Headers", c.headers)
+				w.Header().Set("Access-Control-Max-Age", fmt.Sprint(int(c.maxAge.Seconds())))
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
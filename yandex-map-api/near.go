@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// distanceUnit — единица расстояния для /api/points/near
+type distanceUnit struct {
+	perMeter float64 // сколько единиц в одном метре
+	field    string  // имя поля с расстоянием в ответе
+}
+
+var distanceUnits = map[string]distanceUnit{
+	"m":  {1, "distanceMeters"},
+	"km": {0.001, "distanceKm"},
+	"mi": {1 / 1609.344, "distanceMi"},
+}
+
+const (
+	defaultNearLimit = 20
+	maxNearLimit     = 500
+)
+
+// nearHandler — GET /api/points/near?lat=&lon=[&radius=][&limit=][&units=m|km|mi]
+// Ближайшие к точке лоты по возрастанию расстояния. radius и расстояние в ответе —
+// в единицах units (по умолчанию метры).
+func nearHandler(cache *pointCache, access *accessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method != http.MethodGet {
+			http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+			return
+		}
+
+		tier, ok := access.tierFor(r)
+		if !ok {
+			writeUnknownKey(w)
+			return
+		}
+
+		q := r.URL.Query()
+		lat, err1 := strconv.ParseFloat(q.Get("lat"), 64)
+		lon, err2 := strconv.ParseFloat(q.Get("lon"), 64)
+		if err1 != nil || err2 != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+			http.Error(w, "Требуются корректные параметры lat и lon", http.StatusBadRequest)
+			return
+		}
+
+		unitName := q.Get("units")
+		if unitName == "" {
+			unitName = "m"
+		}
+		unit, ok := distanceUnits[unitName]
+		if !ok {
+			http.Error(w, "Некорректный параметр units (допустимо: m, km, mi)", http.StatusBadRequest)
+			return
+		}
+
+		var radiusMeters float64
+		if v := q.Get("radius"); v != "" {
+			radius, err := strconv.ParseFloat(v, 64)
+			if err != nil || radius <= 0 {
+				http.Error(w, "Некорректный параметр radius", http.StatusBadRequest)
+				return
+			}
+			radiusMeters = radius / unit.perMeter
+		}
+
+		limit := defaultNearLimit
+		if v := q.Get("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				http.Error(w, "Некорректный параметр limit", http.StatusBadRequest)
+				return
+			}
+			limit = min(n, maxNearLimit)
+		}
+
+		data, err := cache.get(r.Context())
+		if err != nil {
+			writeLoadError(w, err)
+			return
+		}
+
+		type candidate struct {
+			point    LotPoint
+			distance float64
+		}
+		var found []candidate
+		for _, p := range data.Points {
+			d := haversineMeters(lat, lon, p.Lat, p.Lon)
+			if radiusMeters > 0 && d > radiusMeters {
+				continue
+			}
+			p.Raw = nil
+			found = append(found, candidate{p, d})
+		}
+		sort.SliceStable(found, func(i, j int) bool { return found[i].distance < found[j].distance })
+		if len(found) > limit {
+			found = found[:limit]
+		}
+
+		out := make([]map[string]interface{}, 0, len(found))
+		for _, c := range found {
+			projected := []LotPoint{c.point}
+			tier.project(projected)
+			c.point = projected[0]
+			m := pointMap(c.point)
+			m[unit.field] = c.distance * unit.perMeter
+			out = append(out, m)
+		}
+		if err := json.NewEncoder(w).Encode(out); err != nil {
+			log.Printf("❌ Ошибка отправки JSON: %v", err)
+		}
+	}
+}
+
+// pointMap — точка как map с теми же ключами, что и в JSON-ответе,
+// чтобы добавлять к ней вычисляемые поля
+func pointMap(p LotPoint) map[string]interface{} {
+	b, _ := json.Marshal(p)
+	var m map[string]interface{}
+	json.Unmarshal(b, &m)
+	return m
+}
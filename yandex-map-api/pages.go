@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"google.golang.org/api/sheets/v4"
+)
+
+// pageResult — строки одной страницы; from — номер её первой строки в таблице
+type pageResult struct {
+	from int
+	rows [][]interface{}
+	err  error
+}
+
+// readPagedRows — читает диапазон данных страницами по pageRows строк.
+// Вместе с текущей страницей запрашиваются до prefetchPages следующих, так что сетевые задержки
+// перекрываются; глубина ограничена, чтобы не держать в памяти лишние страницы.
+// Чтение идёт до конца листа (gridProperties.rowCount) или до dataLastRow, так что пустой блок
+// строк посреди листа не обрывает выдачу. Если размер листа узнать не удалось, чтение
+// заканчивается на первой пустой странице — строки после пустого блока длиной в страницу теряются.
+// Строки возвращаются подряд, начиная с firstRow: пропуски внутри страниц заполняются пустыми строками.
+func (l *sheetLoader) readPagedRows(ctx context.Context, firstRow int) ([][]interface{}, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	lastRow, stopOnEmpty := dataLastRow, true
+	if n, err := l.sheetRowCount(ctx); err != nil {
+		log.Printf("⚠️ Не удалось узнать число строк листа, читаем до первой пустой страницы: %v", err)
+	} else {
+		lastRow, stopOnEmpty = min(n, dataLastRow), false
+	}
+
+	depth := l.prefetchPages
+	if depth < 0 {
+		depth = 0
+	}
+
+	var pending []chan pageResult
+	next := firstRow
+	startPage := func() {
+		if next > lastRow {
+			return
+		}
+		from, to := next, min(next+l.pageRows-1, lastRow)
+		next = to + 1
+
+		ch := make(chan pageResult, 1)
+		pending = append(pending, ch)
+		go func() {
+			rows, err := l.readPage(ctx, from, to)
+			ch <- pageResult{from: from, rows: rows, err: err}
+		}()
+	}
+
+	for i := 0; i <= depth; i++ {
+		startPage()
+	}
+
+	var all [][]interface{}
+	for len(pending) > 0 {
+		res := <-pending[0]
+		pending = pending[1:]
+		if res.err != nil {
+			return nil, res.err
+		}
+		if len(res.rows) == 0 && stopOnEmpty {
+			break
+		}
+		startPage()
+		if len(res.rows) == 0 {
+			continue
+		}
+
+		for len(all) < res.from-firstRow {
+			all = append(all, nil)
+		}
+		all = append(all, res.rows...)
+	}
+	return all, nil
+}
+
+// sheetRowCount — сколько строк в текущем листе (вместе с пустыми в конце)
+func (l *sheetLoader) sheetRowCount(ctx context.Context) (int, error) {
+	var resp *sheets.Spreadsheet
+	err := withSheetsRetry(ctx, "чтение размера листа", func() (err error) {
+		resp, err = l.service.Spreadsheets.Get(l.sheetID).
+			Fields("sheets.properties(title,gridProperties.rowCount)").Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	name := l.sheet()
+	for _, sh := range resp.Sheets {
+		if sh.Properties != nil && sh.Properties.Title == name && sh.Properties.GridProperties != nil {
+			return int(sh.Properties.GridProperties.RowCount), nil
+		}
+	}
+	return 0, fmt.Errorf("лист %q не найден", name)
+}
+
+// readPage — одна страница строк from..to текущего листа
+func (l *sheetLoader) readPage(ctx context.Context, from, to int) ([][]interface{}, error) {
+	pageRange := a1Range(l.sheet(), fmt.Sprintf("%d:%d", from, to))
+	var resp *sheets.ValueRange
+	err := withSheetsRetry(ctx, fmt.Sprintf("чтение строк %d–%d", from, to), func() (err error) {
+		resp, err = l.service.Spreadsheets.Values.Get(l.sheetID, pageRange).Context(ctx).Do()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Values, nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/api/option"
+	"google.golang.org/api/sheets/v4"
+)
+
+// fakeSheet — лист из rowCount строк для httptest-сервера вместо Sheets API; строки
+// из blank пустые. Как и настоящий API, сервер не возвращает пустые строки в конце диапазона.
+// latency — задержка каждого ответа, как сетевая задержка до Google.
+type fakeSheet struct {
+	rowCount int
+	blank    func(row int) bool
+	latency  time.Duration
+}
+
+func (f *fakeSheet) row(n int) []interface{} {
+	if n > f.rowCount || (f.blank != nil && f.blank(n)) {
+		return nil
+	}
+	return []interface{}{strconv.Itoa(n), `{"point":{"lat":55.8,"lon":49.1}}`}
+}
+
+func (f *fakeSheet) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	time.Sleep(f.latency)
+	w.Header().Set("Content-Type", "application/json")
+	path, _ := url.PathUnescape(r.URL.Path)
+	_, rng, isValues := strings.Cut(path, "/values/")
+	if !isValues {
+		json.NewEncoder(w).Encode(map[string]interface{}{"sheets": []interface{}{map[string]interface{}{
+			"properties": map[string]interface{}{"title": "Лист1", "gridProperties": map[string]int{"rowCount": f.rowCount}},
+		}}})
+		return
+	}
+	_, bounds, _ := strings.Cut(rng, "!")
+	fromStr, toStr, _ := strings.Cut(bounds, ":")
+	from, _ := strconv.Atoi(fromStr)
+	to, _ := strconv.Atoi(toStr)
+	var values [][]interface{}
+	for n := from; n <= to; n++ {
+		values = append(values, f.row(n))
+	}
+	for len(values) > 0 && values[len(values)-1] == nil {
+		values = values[:len(values)-1]
+	}
+	for i := range values {
+		if values[i] == nil {
+			values[i] = []interface{}{}
+		}
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"range": rng, "values": values})
+}
+
+// fakeSheetLoader — загрузчик, читающий fakeSheet страницами по pageRows строк,
+// с prefetchPages страницами наперёд
+func fakeSheetLoader(tb testing.TB, sheet *fakeSheet, pageRows, prefetchPages int) *sheetLoader {
+	tb.Helper()
+	srv := httptest.NewServer(sheet)
+	tb.Cleanup(srv.Close)
+	svc, err := sheets.NewService(context.Background(), option.WithEndpoint(srv.URL), option.WithoutAuthentication())
+	if err != nil {
+		tb.Fatalf("sheets.NewService: %v", err)
+	}
+	return &sheetLoader{service: svc, sheetID: "test", sheetName: "Лист1",
+		pageRows: pageRows, prefetchPages: prefetchPages, sheetState: &sheetState{}}
+}
+
+func TestReadPagedRowsSkipsBlankBlock(t *testing.T) {
+	// Пустой блок длиннее страницы посреди листа не должен обрывать чтение
+	sheet := &fakeSheet{rowCount: 1000, blank: func(n int) bool { return n >= 200 && n < 450 }}
+	l := fakeSheetLoader(t, sheet, 100, 2)
+
+	rows, err := l.readPagedRows(context.Background(), dataFirstRow)
+	if err != nil {
+		t.Fatalf("readPagedRows: %v", err)
+	}
+	if len(rows) != 1000-dataFirstRow+1 {
+		t.Fatalf("прочитано %d строк, ожидалось %d", len(rows), 1000-dataFirstRow+1)
+	}
+	for i, row := range rows {
+		n := dataFirstRow + i
+		want := sheet.row(n)
+		if len(want) == 0 {
+			if len(row) != 0 {
+				t.Fatalf("строка %d должна быть пустой: %v", n, row)
+			}
+			continue
+		}
+		if len(row) == 0 || row[0] != want[0] {
+			t.Fatalf("строка %d: %v, ожидалось %v", n, row, want)
+		}
+	}
+}
+
+// BenchmarkReadPagedRows — 10 000 строк страницами по 1000 при задержке ответа 10 мс:
+// prefetch=0 читает страницы по очереди, prefetch≥1 перекрывает задержки соседних страниц
+func BenchmarkReadPagedRows(b *testing.B) {
+	sheet := &fakeSheet{rowCount: dataLastRow, latency: 10 * time.Millisecond}
+	for _, prefetch := range []int{0, 1, 3} {
+		b.Run("prefetch="+strconv.Itoa(prefetch), func(b *testing.B) {
+			l := fakeSheetLoader(b, sheet, 1000, prefetch)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := l.readPagedRows(context.Background(), dataFirstRow); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
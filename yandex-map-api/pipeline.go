@@ -0,0 +1,295 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"math"
+	"os"
+	"strings"
+)
+
+// pointStep — шаг обработки точек после разбора таблицы; может менять, добавлять и убирать точки
+type pointStep func(points []LotPoint) []LotPoint
+
+// pipelineStepDef — описание шага в файле PIPELINE_FILE
+type pipelineStepDef struct {
+	Step   string          `json:"step"`
+	Params json.RawMessage `json:"params"`
+}
+
+// namedStep — шаг вместе с именем (для журнала)
+type namedStep struct {
+	name  string
+	apply pointStep
+}
+
+// pipeline — упорядоченный список шагов обработки
+type pipeline []namedStep
+
+// pipelineSteps — известные шаги: имя → конструктор по параметрам из файла
+var pipelineSteps = map[string]func(params json.RawMessage) (pointStep, error){
+	"trim":    newTrimStep,
+	"round":   newRoundStep,
+	"snap":    newSnapStep,
+	"fuzz":    newFuzzStep,
+	"filter":  newFilterStep,
+	"default": newDefaultStep,
+}
+
+// stringFields — текстовые поля точки, с которыми работают шаги (имена как в JSON-ответе)
+var stringFields = map[string]func(p *LotPoint) *string{
+	"lotName":        func(p *LotPoint) *string { return &p.LotName },
+	"lotDescription": func(p *LotPoint) *string { return &p.LotDescription },
+	"title":          func(p *LotPoint) *string { return &p.Title },
+	"link":           func(p *LotPoint) *string { return &p.Link },
+	"linkText":       func(p *LotPoint) *string { return &p.LinkText },
+	"category":       func(p *LotPoint) *string { return &p.Category },
+	"subcategory":    func(p *LotPoint) *string { return &p.Subcategory },
+	"region":         func(p *LotPoint) *string { return &p.Region },
+	"status":         func(p *LotPoint) *string { return &p.Status },
+	"thumbUrl":       func(p *LotPoint) *string { return &p.ThumbURL },
+	"imageUrl":       func(p *LotPoint) *string { return &p.ImageURL },
+}
+
+// parsePipeline — читает файл конвейера: JSON-массив шагов, например
+//
+//	[{"step": "trim"}, {"step": "filter", "params": {"field": "status", "exclude": ["Продан"]}},
+//	 {"step": "round", "params": {"digits": 4}}]
+//
+// Неизвестный шаг или неверные параметры — ошибка.
+func parsePipeline(path string) (pipeline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var defs []pipelineStepDef
+	if err := json.Unmarshal(data, &defs); err != nil {
+		return nil, fmt.Errorf("ожидается JSON-массив шагов: %w", err)
+	}
+	p := make(pipeline, 0, len(defs))
+	for i, def := range defs {
+		build, ok := pipelineSteps[def.Step]
+		if !ok {
+			return nil, fmt.Errorf("шаг %d: неизвестный шаг %q", i+1, def.Step)
+		}
+		apply, err := build(def.Params)
+		if err != nil {
+			return nil, fmt.Errorf("шаг %d (%s): %w", i+1, def.Step, err)
+		}
+		p = append(p, namedStep{name: def.Step, apply: apply})
+	}
+	return p, nil
+}
+
+// run — прогоняет точки через все шаги по порядку
+func (p pipeline) run(points []LotPoint) []LotPoint {
+	for _, s := range p {
+		before := len(points)
+		points = s.apply(points)
+		if len(points) != before {
+			log.Printf("ℹ️ Шаг %s: точек было %d, стало %d", s.name, before, len(points))
+		}
+	}
+	return points
+}
+
+// names — имена шагов по порядку (для журнала при запуске)
+func (p pipeline) names() []string {
+	names := make([]string, len(p))
+	for i, s := range p {
+		names[i] = s.name
+	}
+	return names
+}
+
+// decodeParams — разбирает параметры шага; неизвестные ключи — ошибка (частая опечатка)
+func decodeParams(params json.RawMessage, dst interface{}) error {
+	if len(params) == 0 || string(params) == "null" {
+		return nil
+	}
+	dec := json.NewDecoder(strings.NewReader(string(params)))
+	dec.DisallowUnknownFields()
+	return dec.Decode(dst)
+}
+
+// fieldAccessors — доступ к перечисленным текстовым полям; пустой список — все поля
+func fieldAccessors(fields []string) ([]func(p *LotPoint) *string, error) {
+	if len(fields) == 0 {
+		all := make([]func(p *LotPoint) *string, 0, len(stringFields))
+		for _, f := range stringFields {
+			all = append(all, f)
+		}
+		return all, nil
+	}
+	acc := make([]func(p *LotPoint) *string, 0, len(fields))
+	for _, name := range fields {
+		f, ok := stringFields[name]
+		if !ok {
+			return nil, fmt.Errorf("неизвестное поле %q", name)
+		}
+		acc = append(acc, f)
+	}
+	return acc, nil
+}
+
+// trim {"fields": [...]} — убирает пробелы по краям и повторные пробелы внутри
+func newTrimStep(params json.RawMessage) (pointStep, error) {
+	var cfg struct {
+		Fields []string `json:"fields"`
+	}
+	if err := decodeParams(params, &cfg); err != nil {
+		return nil, err
+	}
+	fields, err := fieldAccessors(cfg.Fields)
+	if err != nil {
+		return nil, err
+	}
+	return func(points []LotPoint) []LotPoint {
+		for i := range points {
+			for _, f := range fields {
+				s := f(&points[i])
+				*s = strings.Join(strings.Fields(*s), " ")
+			}
+		}
+		return points
+	}, nil
+}
+
+// round {"digits": 4} — округляет координаты до заданного числа знаков после запятой
+func newRoundStep(params json.RawMessage) (pointStep, error) {
+	var cfg struct {
+		Digits *int `json:"digits"`
+	}
+	if err := decodeParams(params, &cfg); err != nil {
+		return nil, err
+	}
+	if cfg.Digits == nil || *cfg.Digits < 0 || *cfg.Digits > 10 {
+		return nil, fmt.Errorf("нужен параметр digits от 0 до 10")
+	}
+	scale := math.Pow10(*cfg.Digits)
+	return func(points []LotPoint) []LotPoint {
+		for i := range points {
+			points[i].Lat = math.Round(points[i].Lat*scale) / scale
+			points[i].Lon = math.Round(points[i].Lon*scale) / scale
+		}
+		return points
+	}, nil
+}
+
+// snap {"step": 0.01} — переносит координаты в центры ячеек сетки (как SNAP_GRID)
+func newSnapStep(params json.RawMessage) (pointStep, error) {
+	var cfg struct {
+		Step float64 `json:"step"`
+	}
+	if err := decodeParams(params, &cfg); err != nil {
+		return nil, err
+	}
+	if cfg.Step <= 0 || cfg.Step > 10 {
+		return nil, fmt.Errorf("нужен параметр step от 0 до 10 градусов")
+	}
+	return func(points []LotPoint) []LotPoint {
+		for i := range points {
+			points[i].Lat = snapToGrid(points[i].Lat, cfg.Step)
+			points[i].Lon = snapToGrid(points[i].Lon, cfg.Step)
+		}
+		return points
+	}, nil
+}
+
+// fuzz {"meters": 200} — сдвигает точку на случайное расстояние до meters метров.
+// Сдвиг выводится из данных лота, поэтому при каждом обновлении он один и тот же:
+// иначе усреднением нескольких ответов можно было бы найти точное место.
+func newFuzzStep(params json.RawMessage) (pointStep, error) {
+	var cfg struct {
+		Meters float64 `json:"meters"`
+	}
+	if err := decodeParams(params, &cfg); err != nil {
+		return nil, err
+	}
+	if cfg.Meters <= 0 || cfg.Meters > 100000 {
+		return nil, fmt.Errorf("нужен параметр meters от 0 до 100000")
+	}
+	return func(points []LotPoint) []LotPoint {
+		for i := range points {
+			p := &points[i]
+			h := fnv.New64a()
+			fmt.Fprintf(h, "%s|%s|%g|%g", p.LotName, p.Link, p.Lat, p.Lon)
+			sum := h.Sum64()
+			angle := float64(sum>>32) / (1 << 32) * 2 * math.Pi
+			// sqrt — чтобы точки распределялись по площади круга равномерно
+			dist := math.Sqrt(float64(sum&0xffffffff)/(1<<32)) * cfg.Meters
+			dLat := dist * math.Cos(angle) / metersPerDegreeLat
+			dLon := dist * math.Sin(angle) / (metersPerDegreeLat * math.Max(math.Cos(p.Lat*math.Pi/180), 0.01))
+			p.Lat = math.Max(-90, math.Min(90, p.Lat+dLat))
+			p.Lon = math.Max(-180, math.Min(180, p.Lon+dLon))
+		}
+		return points
+	}, nil
+}
+
+// filter {"field": "status", "include": [...], "exclude": [...]} — оставляет точки, у которых
+// значение поля есть в include (если задан) и нет в exclude; сравнение без учёта регистра
+func newFilterStep(params json.RawMessage) (pointStep, error) {
+	var cfg struct {
+		Field   string   `json:"field"`
+		Include []string `json:"include"`
+		Exclude []string `json:"exclude"`
+	}
+	if err := decodeParams(params, &cfg); err != nil {
+		return nil, err
+	}
+	field, ok := stringFields[cfg.Field]
+	if !ok {
+		return nil, fmt.Errorf("неизвестное поле %q", cfg.Field)
+	}
+	if len(cfg.Include) == 0 && len(cfg.Exclude) == 0 {
+		return nil, fmt.Errorf("нужен include или exclude")
+	}
+	set := func(values []string) map[string]bool {
+		m := make(map[string]bool, len(values))
+		for _, v := range values {
+			m[strings.ToLower(strings.TrimSpace(v))] = true
+		}
+		return m
+	}
+	include, exclude := set(cfg.Include), set(cfg.Exclude)
+	return func(points []LotPoint) []LotPoint {
+		kept := points[:0]
+		for _, p := range points {
+			v := strings.ToLower(strings.TrimSpace(*field(&p)))
+			if (len(include) > 0 && !include[v]) || exclude[v] {
+				continue
+			}
+			kept = append(kept, p)
+		}
+		return kept
+	}, nil
+}
+
+// default {"field": "lotName", "value": "Без названия"} — значение для пустого поля
+func newDefaultStep(params json.RawMessage) (pointStep, error) {
+	var cfg struct {
+		Field string `json:"field"`
+		Value string `json:"value"`
+	}
+	if err := decodeParams(params, &cfg); err != nil {
+		return nil, err
+	}
+	field, ok := stringFields[cfg.Field]
+	if !ok {
+		return nil, fmt.Errorf("неизвестное поле %q", cfg.Field)
+	}
+	if cfg.Value == "" {
+		return nil, fmt.Errorf("нужен параметр value")
+	}
+	return func(points []LotPoint) []LotPoint {
+		for i := range points {
+			if s := field(&points[i]); strings.TrimSpace(*s) == "" {
+				*s = cfg.Value
+			}
+		}
+		return points
+	}, nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Цены в колонке Price редакторы пишут как придётся: "1 200 000", "1,2 млн ₽", "$850K",
+// "950 тыс. руб.". parsePrice приводит их к числу и коду валюты.
+
+// defaultPriceCurrency — валюта цены без обозначения валюты (PRICE_DEFAULT_CURRENCY)
+const defaultPriceCurrency = "RUB"
+
+// priceCurrencies — обозначения валют (в нижнем регистре) → код ISO 4217
+var priceCurrencies = map[string]string{
+	"₽": "RUB", "р.": "RUB", "руб": "RUB", "руб.": "RUB", "рубль": "RUB", "рубля": "RUB", "рублей": "RUB", "rub": "RUB",
+	"$": "USD", "usd": "USD",
+	"€": "EUR", "eur": "EUR",
+}
+
+// priceMultipliers — сокращения разрядов (в нижнем регистре; "к" — кириллическая)
+var priceMultipliers = map[string]float64{
+	"k": 1e3, "к": 1e3, "тыс": 1e3, "тыс.": 1e3,
+	"m": 1e6, "mln": 1e6, "млн": 1e6, "млн.": 1e6,
+	"b": 1e9, "bn": 1e9, "млрд": 1e9, "млрд.": 1e9,
+}
+
+var (
+	// priceNumberRe — числовая часть цены: цифры с пробелами-разделителями разрядов, точками и запятыми
+	priceNumberRe = regexp.MustCompile(`\d[\d\s.,]*`)
+	// priceTokenRe — слова и символы вокруг числа: валюта или разряд
+	priceTokenRe = regexp.MustCompile(`[a-zа-яё]+\.?|[₽$€]`)
+)
+
+// parsePrice — значение цены и код валюты ("" — валюта не указана). Ошибка — если в ячейке
+// не одно число или есть слова, не похожие ни на валюту, ни на разряд.
+func parsePrice(s string) (float64, string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	// Неразрывные и узкие пробелы — частые разделители разрядов при вставке из документов
+	s = strings.NewReplacer("\u00a0", " ", "\u2009", " ", "\u202f", " ").Replace(s)
+
+	numbers := priceNumberRe.FindAllStringIndex(s, -1)
+	if len(numbers) != 1 {
+		return 0, "", fmt.Errorf("ожидается одно число")
+	}
+	start, end := numbers[0][0], numbers[0][1]
+	value, err := parsePriceNumber(strings.TrimRight(strings.TrimSpace(s[start:end]), ".,"))
+	if err != nil {
+		return 0, "", err
+	}
+
+	rest := s[:start] + " " + s[end:]
+	var currency string
+	multiplier := 1.0
+	for _, tok := range priceTokenRe.FindAllString(rest, -1) {
+		if c, ok := priceCurrencies[tok]; ok {
+			if currency != "" && currency != c {
+				return 0, "", fmt.Errorf("указано несколько валют")
+			}
+			currency = c
+			continue
+		}
+		if m, ok := priceMultipliers[tok]; ok && multiplier == 1 {
+			multiplier = m
+			continue
+		}
+		return 0, "", fmt.Errorf("непонятное обозначение %q", tok)
+	}
+	if leftover := strings.TrimSpace(priceTokenRe.ReplaceAllString(rest, "")); leftover != "" {
+		return 0, "", fmt.Errorf("непонятные символы %q", leftover)
+	}
+	return value * multiplier, currency, nil
+}
+
+// parsePriceNumber — число с разделителями разрядов. Пробелы отбрасываются; если есть и точка,
+// и запятая, дробная часть — после последнего из них. Запятая или точка, встретившиеся
+// несколько раз, — разделители разрядов ("1.200.000"); единственная запятая перед ровно
+// тремя цифрами — тоже ("1,200"), в остальных случаях это дробная часть ("1,5").
+func parsePriceNumber(s string) (float64, error) {
+	s = strings.Join(strings.Fields(s), "")
+	dot, comma := strings.LastIndex(s, "."), strings.LastIndex(s, ",")
+	switch {
+	case dot >= 0 && comma >= 0:
+		if comma > dot {
+			s = strings.Replace(strings.ReplaceAll(s, ".", ""), ",", ".", 1)
+		} else {
+			s = strings.ReplaceAll(s, ",", "")
+		}
+	case strings.Count(s, ",") > 1:
+		s = strings.ReplaceAll(s, ",", "")
+	case comma >= 0 && len(s)-comma-1 == 3:
+		s = strings.ReplaceAll(s, ",", "")
+	case comma >= 0:
+		s = strings.Replace(s, ",", ".", 1)
+	case strings.Count(s, ".") > 1:
+		s = strings.ReplaceAll(s, ".", "")
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("некорректное число %q", s)
+	}
+	return f, nil
+}
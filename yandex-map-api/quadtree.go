@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+const (
+	// defaultQuadtreeDepth — сколько уровней под корнем раскрывать за один запрос
+	defaultQuadtreeDepth = 2
+	// maxQuadtreeDepth — предел depth: на каждом уровне узлов до 4 раз больше
+	maxQuadtreeDepth = 6
+	// maxQuadtreeThreshold — предел параметра threshold
+	maxQuadtreeThreshold = 1000
+	// minQuadtreeCellDeg — узел уже этого (в градусах) не делится: в нём точки с почти
+	// одинаковыми координатами, и дробить его дальше бесполезно
+	minQuadtreeCellDeg = 1e-6
+)
+
+// worldBBox — вся карта: корень дерева без bbox
+var worldBBox = bbox{-180, -90, 180, 90}
+
+// quadtreeNode — узел дерева. Если точек в узле не больше порога, в нём сами точки (Points);
+// иначе — только число и центр масс, а Children — четверти, если до них хватило depth.
+// Узел с Count без Points и Children клиент раскрывает запросом с bbox=Bounds.
+type quadtreeNode struct {
+	Bounds   bbox            `json:"bounds"`
+	Count    int             `json:"count"`
+	Centroid *coordinate     `json:"centroid,omitempty"`
+	Points   []LotPoint      `json:"points,omitempty"`
+	Children []*quadtreeNode `json:"children,omitempty"`
+}
+
+type quadtreeResponse struct {
+	Threshold int           `json:"threshold"`
+	Depth     int           `json:"depth"`
+	Root      *quadtreeNode `json:"root"`
+}
+
+// quadtreeHandler — GET /api/points/quadtree[?bbox=minLon,minLat,maxLon,maxLat][&depth=][&threshold=]
+// Дерево для постепенной загрузки карты: плотные области приходят числом, редкие — точками.
+// threshold по умолчанию — QUADTREE_THRESHOLD; дочерние узлы делят bbox родителя пополам по
+// каждой оси, так что запрос с bbox узла возвращает то же поддерево, раскрытое глубже.
+func quadtreeHandler(cache *pointCache, access *accessControl, defaultThreshold int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method != http.MethodGet {
+			http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+			return
+		}
+
+		tier, ok := access.tierFor(r)
+		if !ok {
+			writeUnknownKey(w)
+			return
+		}
+
+		q := r.URL.Query()
+		depth := defaultQuadtreeDepth
+		if v := q.Get("depth"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 || n > maxQuadtreeDepth {
+				http.Error(w, "Некорректный параметр depth (0–"+strconv.Itoa(maxQuadtreeDepth)+")", http.StatusBadRequest)
+				return
+			}
+			depth = n
+		}
+		threshold := defaultThreshold
+		if v := q.Get("threshold"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > maxQuadtreeThreshold {
+				http.Error(w, "Некорректный параметр threshold (1–"+strconv.Itoa(maxQuadtreeThreshold)+")", http.StatusBadRequest)
+				return
+			}
+			threshold = n
+		}
+		area := worldBBox
+		if v := q.Get("bbox"); v != "" {
+			b, err := parseBBox(v)
+			if err != nil {
+				http.Error(w, "Некорректный параметр bbox: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			area = b
+		}
+
+		data, err := cache.get(r.Context())
+		if err != nil {
+			writeLoadError(w, err)
+			return
+		}
+
+		points := make([]LotPoint, 0, len(data.Points))
+		for _, p := range data.Points {
+			if !area.contains(p.Lat, p.Lon) {
+				continue
+			}
+			p.Raw = nil
+			points = append(points, p)
+		}
+		tier.project(points)
+
+		resp := quadtreeResponse{Threshold: threshold, Depth: depth, Root: buildQuadtree(points, area, depth, threshold)}
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			log.Printf("❌ Ошибка отправки JSON: %v", err)
+		}
+	}
+}
+
+// buildQuadtree — узел для точек внутри bounds. Точка на границе четвертей уходит в
+// восточную (северную) — каждая точка попадает ровно в одного потомка. Пустые четверти
+// не возвращаются.
+func buildQuadtree(points []LotPoint, bounds bbox, depth, threshold int) *quadtreeNode {
+	node := &quadtreeNode{Bounds: bounds, Count: len(points)}
+	small := bounds[2]-bounds[0] < minQuadtreeCellDeg && bounds[3]-bounds[1] < minQuadtreeCellDeg
+	if len(points) <= threshold || small {
+		node.Points = points
+		return node
+	}
+
+	var sumLat, sumLon float64
+	for _, p := range points {
+		sumLat += p.Lat
+		sumLon += p.Lon
+	}
+	node.Centroid = &coordinate{Lat: sumLat / float64(len(points)), Lon: sumLon / float64(len(points))}
+	if depth == 0 {
+		return node
+	}
+
+	midLon, midLat := (bounds[0]+bounds[2])/2, (bounds[1]+bounds[3])/2
+	quads := [4]bbox{
+		{bounds[0], bounds[1], midLon, midLat}, // юго-запад
+		{midLon, bounds[1], bounds[2], midLat}, // юго-восток
+		{bounds[0], midLat, midLon, bounds[3]}, // северо-запад
+		{midLon, midLat, bounds[2], bounds[3]}, // северо-восток
+	}
+	var parts [4][]LotPoint
+	for _, p := range points {
+		i := 0
+		if p.Lon >= midLon {
+			i |= 1
+		}
+		if p.Lat >= midLat {
+			i |= 2
+		}
+		parts[i] = append(parts[i], p)
+	}
+	for i, part := range parts {
+		if len(part) > 0 {
+			node.Children = append(node.Children, buildQuadtree(part, quads[i], depth-1, threshold))
+		}
+	}
+	return node
+}
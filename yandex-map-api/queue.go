@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// requestQueue — ограничение одновременных запросов к /api/ с очередью ожидания.
+// Вместо немедленного 503 запрос ждёт свободного места до maxWait — обычно этого
+// хватает, чтобы дождаться общей загрузки кэша. Очередь без места или истёкшее
+// ожидание — 503.
+type requestQueue struct {
+	slots    chan struct{}
+	maxQueue int64
+	maxWait  time.Duration
+
+	depth    atomic.Int64
+	waitSum  *counter
+	waitCnt  *counter
+	rejected *counter
+}
+
+func newRequestQueue(maxConcurrent, maxQueue int, maxWait time.Duration) *requestQueue {
+	q := &requestQueue{
+		slots:    make(chan struct{}, maxConcurrent),
+		maxQueue: int64(maxQueue),
+		maxWait:  maxWait,
+		waitSum:  metrics.counter("request_queue_wait_seconds_sum", "Суммарное время ожидания в очереди запросов"),
+		waitCnt:  metrics.counter("request_queue_wait_seconds_count", "Сколько запросов ждали в очереди"),
+		rejected: metrics.counter("request_queue_rejected_total", "Запросы, отклонённые из-за переполнения очереди"),
+	}
+	metrics.gauge("request_queue_depth", "Запросы, ожидающие в очереди", func() float64 {
+		return float64(q.depth.Load())
+	})
+	return q
+}
+
+// middleware — пропускает запросы к /api/ через очередь, остальные — напрямую
+func (q *requestQueue) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		select {
+		case q.slots <- struct{}{}:
+		default:
+			if !q.wait(r) {
+				q.rejected.Inc()
+				writeAPIError(w, http.StatusServiceUnavailable, errCodeOverloaded,
+					"Сервер перегружен, повторите запрос позже", time.Second)
+				return
+			}
+		}
+		defer func() { <-q.slots }()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// wait — ждёт свободного места; false, если очередь полна, истекло ожидание или отменён запрос
+func (q *requestQueue) wait(r *http.Request) bool {
+	if q.depth.Add(1) > q.maxQueue {
+		q.depth.Add(-1)
+		return false
+	}
+	defer q.depth.Add(-1)
+
+	start := time.Now()
+	defer func() {
+		q.waitSum.Add(time.Since(start).Seconds())
+		q.waitCnt.Inc()
+	}()
+
+	t := time.NewTimer(q.maxWait)
+	defer t.Stop()
+	select {
+	case q.slots <- struct{}{}:
+		return true
+	case <-t.C:
+		return false
+	case <-r.Context().Done():
+		return false
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"strings"
+)
+
+// regionCentroids — приблизительные координаты регионов и крупных городов России
+// (для регионов — координаты административного центра). Ключи — название без слов
+// «область», «край», «республика» и т.п. в нижнем регистре, см. normalizeRegion.
+var regionCentroids = map[string]latLon{
+	// Города федерального значения и крупные города
+	"москва":          {55.7558, 37.6173},
+	"санкт-петербург": {59.9311, 30.3609},
+	"петербург":       {59.9311, 30.3609},
+	"спб":             {59.9311, 30.3609},
+	"севастополь":     {44.6166, 33.5254},
+	"новосибирск":     {55.0084, 82.9357},
+	"екатеринбург":    {56.8389, 60.6057},
+	"казань":          {55.7963, 49.1088},
+	"нижний новгород": {56.2965, 43.9361},
+	"челябинск":       {55.1644, 61.4368},
+	"самара":          {53.1959, 50.1002},
+	"омск":            {54.9885, 73.3242},
+	"ростов-на-дону":  {47.2357, 39.7015},
+	"уфа":             {54.7388, 55.9721},
+	"красноярск":      {56.0153, 92.8932},
+	"воронеж":         {51.6720, 39.1843},
+	"пермь":           {58.0105, 56.2502},
+	"волгоград":       {48.7080, 44.5133},
+	"краснодар":       {45.0355, 38.9753},
+	"сочи":            {43.5855, 39.7231},
+	"владивосток":     {43.1155, 131.8855},
+
+	// Области
+	"московская":      {55.5043, 38.0353},
+	"ленинградская":   {59.9311, 30.3609},
+	"амурская":        {50.2907, 127.5272},
+	"архангельская":   {64.5393, 40.5187},
+	"астраханская":    {46.3479, 48.0336},
+	"белгородская":    {50.5997, 36.5983},
+	"брянская":        {53.2434, 34.3634},
+	"владимирская":    {56.1290, 40.4066},
+	"волгоградская":   {48.7080, 44.5133},
+	"вологодская":     {59.2181, 39.8886},
+	"воронежская":     {51.6720, 39.1843},
+	"ивановская":      {57.0000, 40.9737},
+	"иркутская":       {52.2870, 104.3050},
+	"калининградская": {54.7104, 20.4522},
+	"калужская":       {54.5293, 36.2754},
+	"кемеровская":     {55.3547, 86.0873},
+	"кировская":       {58.6036, 49.6680},
+	"костромская":     {57.7677, 40.9264},
+	"курганская":      {55.4410, 65.3411},
+	"курская":         {51.7304, 36.1926},
+	"липецкая":        {52.6088, 39.5992},
+	"магаданская":     {59.5682, 150.8085},
+	"мурманская":      {68.9585, 33.0827},
+	"нижегородская":   {56.2965, 43.9361},
+	"новгородская":    {58.5215, 31.2755},
+	"новосибирская":   {55.0084, 82.9357},
+	"омская":          {54.9885, 73.3242},
+	"оренбургская":    {51.7682, 55.0969},
+	"орловская":       {52.9703, 36.0635},
+	"пензенская":      {53.1959, 45.0183},
+	"псковская":       {57.8194, 28.3318},
+	"ростовская":      {47.2357, 39.7015},
+	"рязанская":       {54.6269, 39.6916},
+	"самарская":       {53.1959, 50.1002},
+	"саратовская":     {51.5336, 46.0343},
+	"сахалинская":     {46.9591, 142.7380},
+	"свердловская":    {56.8389, 60.6057},
+	"смоленская":      {54.7826, 32.0453},
+	"тамбовская":      {52.7212, 41.4523},
+	"тверская":        {56.8587, 35.9176},
+	"томская":         {56.4846, 84.9476},
+	"тульская":        {54.1931, 37.6173},
+	"тюменская":       {57.1522, 65.5272},
+	"ульяновская":     {54.3142, 48.4031},
+	"челябинская":     {55.1644, 61.4368},
+	"ярославская":     {57.6261, 39.8845},
+	"еврейская":       {48.7947, 132.9218},
+
+	// Края
+	"алтайский":      {53.3481, 83.7798},
+	"забайкальский":  {52.0340, 113.4994},
+	"камчатский":     {53.0370, 158.6559},
+	"краснодарский":  {45.0355, 38.9753},
+	"красноярский":   {56.0153, 92.8932},
+	"пермский":       {58.0105, 56.2502},
+	"приморский":     {43.1155, 131.8855},
+	"ставропольский": {45.0428, 41.9734},
+	"хабаровский":    {48.4802, 135.0719},
+
+	// Республики
+	"адыгея":             {44.6098, 40.1006},
+	"алтай":              {51.9581, 85.9603},
+	"башкортостан":       {54.7388, 55.9721},
+	"башкирия":           {54.7388, 55.9721},
+	"бурятия":            {51.8335, 107.5841},
+	"дагестан":           {42.9831, 47.5047},
+	"ингушетия":          {43.1663, 44.8047},
+	"кабардино-балкария": {43.4853, 43.6071},
+	"калмыкия":           {46.3078, 44.2558},
+	"карачаево-черкесия": {44.2233, 42.0578},
+	"карелия":            {61.7849, 34.3469},
+	"коми":               {61.6688, 50.8364},
+	"крым":               {44.9521, 34.1024},
+	"марий эл":           {56.6344, 47.8999},
+	"мордовия":           {54.1874, 45.1839},
+	"саха":               {62.0355, 129.6755},
+	"якутия":             {62.0355, 129.6755},
+	"северная осетия":    {43.0205, 44.6819},
+	"алания":             {43.0205, 44.6819},
+	"татарстан":          {55.7963, 49.1088},
+	"тыва":               {51.7191, 94.4378},
+	"удмуртия":           {56.8526, 53.2045},
+	"хакасия":            {53.7212, 91.4424},
+	"чечня":              {43.3178, 45.6949},
+	"чувашия":            {56.1439, 47.2489},
+
+	// Автономные округа
+	"ненецкий":         {67.6381, 53.0069},
+	"ханты-мансийский": {61.0042, 69.0019},
+	"югра":             {61.0042, 69.0019},
+	"хмао":             {61.0042, 69.0019},
+	"чукотский":        {64.7337, 177.4968},
+	"ямало-ненецкий":   {66.5299, 66.6136},
+	"янао":             {66.5299, 66.6136},
+}
+
+type latLon struct {
+	lat, lon float64
+}
+
+// regionNoiseWords — слова, которые отбрасываются при поиске региона
+var regionNoiseWords = map[string]bool{
+	"область": true, "обл": true, "обл.": true, "край": true, "республика": true, "респ": true, "респ.": true,
+	"автономный": true, "автономная": true, "округ": true, "ао": true, "г": true, "г.": true, "город": true,
+	"—": true, "-": true,
+}
+
+// normalizeRegion — название региона в виде ключа regionCentroids:
+// нижний регистр, ё → е, без служебных слов («Московская обл.» → «московская»)
+func normalizeRegion(s string) string {
+	s = strings.ReplaceAll(strings.ToLower(s), "ё", "е")
+	var words []string
+	s = strings.NewReplacer(",", " ", "(", " ", ")", " ").Replace(s)
+	for _, w := range strings.Fields(s) {
+		if !regionNoiseWords[w] {
+			words = append(words, w)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// regionCentroid — приблизительные координаты региона или города по названию.
+// Если название целиком не найдено, пробуем отдельные слова: «Саха (Якутия)», «ХМАО — Югра».
+func regionCentroid(name string) (latLon, bool) {
+	key := normalizeRegion(name)
+	if p, ok := regionCentroids[key]; ok {
+		return p, true
+	}
+	for _, w := range strings.Fields(key) {
+		if p, ok := regionCentroids[w]; ok {
+			return p, true
+		}
+	}
+	return latLon{}, false
+}
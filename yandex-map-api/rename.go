@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// fieldRenames — переименование полей точки в ответах (FIELD_RENAME): JSON-имя → новое имя.
+// Задаётся один раз при запуске; пустая карта — поля называются как обычно.
+var fieldRenames map[string]string
+
+// pointFieldNames — имена полей точки в JSON-ответе (по json-тегам LotPoint)
+func pointFieldNames() map[string]bool {
+	names := make(map[string]bool)
+	t := reflect.TypeOf(LotPoint{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// parseFieldRenames — разбирает FIELD_RENAME: {"lotName": "name", "link": "url"}.
+// Переименовывать можно только существующие поля, и новые имена не должны совпадать
+// ни между собой, ни с полями, которые остаются под своими именами.
+func parseFieldRenames(s string) (map[string]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	var renames map[string]string
+	if err := json.Unmarshal([]byte(s), &renames); err != nil {
+		return nil, fmt.Errorf("ожидается JSON-объект: %w", err)
+	}
+	known := pointFieldNames()
+	targets := make(map[string]string)
+	for from, to := range renames {
+		if !known[from] {
+			return nil, fmt.Errorf("неизвестное поле %q", from)
+		}
+		if strings.TrimSpace(to) == "" {
+			return nil, fmt.Errorf("пустое новое имя для поля %q", from)
+		}
+		if other, dup := targets[to]; dup {
+			return nil, fmt.Errorf("поля %q и %q переименованы в одно имя %q", other, from, to)
+		}
+		targets[to] = from
+	}
+	for to, from := range targets {
+		if _, renamed := renames[to]; known[to] && !renamed {
+			return nil, fmt.Errorf("поле %q переименовано в %q, но такое поле уже есть", from, to)
+		}
+	}
+	return renames, nil
+}
+
+// outputName — имя поля в ответе с учётом FIELD_RENAME
+func outputName(field string) string {
+	if to, ok := fieldRenames[field]; ok {
+		return to
+	}
+	return field
+}
+
+// pointJSON — LotPoint без собственных методов сериализации (обычные json-теги)
+type pointJSON LotPoint
+
+// MarshalJSON — JSON точки с переименованными полями. Без FIELD_RENAME — как по тегам.
+// Порядок ключей при переименовании алфавитный.
+func (p LotPoint) MarshalJSON() ([]byte, error) {
+	b, err := json.Marshal(pointJSON(p))
+	if err != nil || len(fieldRenames) == 0 {
+		return b, err
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	renamed := make(map[string]json.RawMessage, len(m))
+	for k, v := range m {
+		renamed[outputName(k)] = v
+	}
+	return json.Marshal(renamed)
+}
+
+// EncodeMsgpack — то же для MessagePack: без FIELD_RENAME точка кодируется по json-тегам,
+// как настроено в writeMsgpack, иначе — картой с переименованными ключами
+func (p LotPoint) EncodeMsgpack(enc *msgpack.Encoder) error {
+	if len(fieldRenames) == 0 {
+		return enc.Encode(pointJSON(p))
+	}
+	b, err := json.Marshal(pointJSON(p))
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber() // иначе целые поля превратятся в float64
+	var m map[string]interface{}
+	if err := dec.Decode(&m); err != nil {
+		return err
+	}
+	renamed := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		renamed[outputName(k)] = msgpackNumbers(v)
+	}
+	return enc.Encode(renamed)
+}
+
+// msgpackNumbers — json.Number в int64 или float64 (вложенные карты и массивы тоже)
+func msgpackNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = msgpackNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = msgpackNumbers(item)
+		}
+	}
+	return v
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+// sheetsRetryDelays — расписание повторов, если Google не сообщил, сколько ждать
+var sheetsRetryDelays = []time.Duration{500 * time.Millisecond, 1 * time.Second, 2 * time.Second}
+
+// maxSheetsRetryWait — дольше этого не ждём даже по просьбе Google, сразу отдаём ошибку
+const maxSheetsRetryWait = 10 * time.Second
+
+// sheetsQuota — момент, раньше которого Google просил не обращаться к API
+var sheetsQuota struct {
+	mu            sync.Mutex
+	nextAvailable time.Time
+}
+
+// sheetsNextAvailable — когда квота Sheets снова будет доступна (нулевое время, если ограничения нет)
+func sheetsNextAvailable() time.Time {
+	sheetsQuota.mu.Lock()
+	defer sheetsQuota.mu.Unlock()
+	if time.Now().After(sheetsQuota.nextAvailable) {
+		return time.Time{}
+	}
+	return sheetsQuota.nextAvailable
+}
+
+func setSheetsNextAvailable(t time.Time) {
+	sheetsQuota.mu.Lock()
+	defer sheetsQuota.mu.Unlock()
+	if t.After(sheetsQuota.nextAvailable) {
+		sheetsQuota.nextAvailable = t
+	}
+}
+
+// quotaError — квота Sheets исчерпана дольше, чем мы готовы ждать
+type quotaError struct {
+	until time.Time
+}
+
+func (e *quotaError) Error() string {
+	return "квота Google Sheets исчерпана до " + e.until.Format(time.RFC3339)
+}
+
+// isQuotaExceeded — Google ответил 429 (после всех повторов)
+func isQuotaExceeded(err error) bool {
+	var gerr *googleapi.Error
+	return errors.As(err, &gerr) && gerr.Code == http.StatusTooManyRequests
+}
+
+// isRetryableSheetsError — временные ошибки, которые имеет смысл повторить
+func isRetryableSheetsError(err error) bool {
+	var gerr *googleapi.Error
+	if !errors.As(err, &gerr) {
+		return false
+	}
+	switch gerr.Code {
+	case http.StatusTooManyRequests, http.StatusInternalServerError,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfterFromError — задержка, которую сообщил Google: заголовок Retry-After
+// (секунды или HTTP-дата) либо RetryInfo.retryDelay в деталях ошибки
+func retryAfterFromError(err error) (time.Duration, bool) {
+	var gerr *googleapi.Error
+	if !errors.As(err, &gerr) {
+		return 0, false
+	}
+
+	if v := gerr.Header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+			return time.Duration(secs) * time.Second, true
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return time.Until(t), true
+		}
+	}
+
+	for _, d := range gerr.Details {
+		m, ok := d.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if s, ok := m["retryDelay"].(string); ok {
+			if delay, err := time.ParseDuration(s); err == nil {
+				return delay, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// withSheetsRetry — выполняет обращение к Sheets с повторами при временных ошибках.
+// Если Google сообщил, когда квота освободится, ждём ровно столько, а не по расписанию.
+func withSheetsRetry(ctx context.Context, op string, call func() error) error {
+	for attempt := 0; ; attempt++ {
+		if wait := time.Until(sheetsNextAvailable()); wait > 0 {
+			if wait > maxSheetsRetryWait || !fitsDeadline(ctx, wait) {
+				return &quotaError{until: sheetsNextAvailable()}
+			}
+			if err := sleepCtx(ctx, wait); err != nil {
+				return err
+			}
+		}
+
+		err := call()
+		if err == nil || !isRetryableSheetsError(err) || attempt >= len(sheetsRetryDelays) {
+			return err
+		}
+
+		delay := sheetsRetryDelays[attempt]
+		if d, ok := retryAfterFromError(err); ok {
+			delay = d
+			setSheetsNextAvailable(time.Now().Add(d))
+		}
+		if delay > maxSheetsRetryWait || !fitsDeadline(ctx, delay) {
+			return err
+		}
+
+		log.Printf("⚠️ %s: временная ошибка Sheets, повтор через %v: %v", op, delay, err)
+		if err := sleepCtx(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// fitsDeadline — успеем ли подождать d до истечения срока запроса. Ждать дольше бессмысленно:
+// повтор всё равно будет отменён, а клиент вместо настоящей ошибки Sheets получит таймаут.
+func fitsDeadline(ctx context.Context, d time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	return !ok || time.Until(deadline) > d
+}
+
+// sleepCtx — пауза, прерываемая отменой контекста
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
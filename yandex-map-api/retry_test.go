@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+// resetSheetsQuota — сбрасывает общее ограничение квоты между тестами
+func resetSheetsQuota(t *testing.T) {
+	t.Helper()
+	reset := func() {
+		sheetsQuota.mu.Lock()
+		sheetsQuota.nextAvailable = time.Time{}
+		sheetsQuota.mu.Unlock()
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+func TestWithSheetsRetryStopsAtDeadline(t *testing.T) {
+	resetSheetsQuota(t)
+	unavailable := &googleapi.Error{Code: http.StatusServiceUnavailable}
+
+	tests := []struct {
+		name      string
+		timeout   time.Duration
+		delays    []time.Duration
+		wantCalls int
+	}{
+		// Повтор через 500 мс не успеть за 100 мс — второго обращения нет, и ждать не нужно
+		{"повтор не помещается", 100 * time.Millisecond, []time.Duration{500 * time.Millisecond}, 1},
+		// Все повторы помещаются в срок — используются все
+		{"повторы помещаются", 5 * time.Second, []time.Duration{time.Millisecond, time.Millisecond}, 3},
+		// Первый повтор помещается, второй уже нет
+		{"помещается часть", 200 * time.Millisecond, []time.Duration{10 * time.Millisecond, time.Second}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saved := sheetsRetryDelays
+			sheetsRetryDelays = tt.delays
+			defer func() { sheetsRetryDelays = saved }()
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+			calls := 0
+			start := time.Now()
+			err := withSheetsRetry(ctx, "тест", func() error { calls++; return unavailable })
+			if calls != tt.wantCalls {
+				t.Errorf("обращений %d, ожидалось %d", calls, tt.wantCalls)
+			}
+			if !errors.Is(err, unavailable) {
+				t.Errorf("ошибка %v, ожидалась исходная ошибка Sheets", err)
+			}
+			if elapsed := time.Since(start); elapsed >= tt.timeout {
+				t.Errorf("withSheetsRetry ждал %v — до истечения срока запроса", elapsed)
+			}
+		})
+	}
+}
+
+func TestWithSheetsRetryQuotaBeyondDeadline(t *testing.T) {
+	resetSheetsQuota(t)
+	setSheetsNextAvailable(time.Now().Add(time.Second))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	calls := 0
+	err := withSheetsRetry(ctx, "тест", func() error { calls++; return nil })
+	var qerr *quotaError
+	if !errors.As(err, &qerr) {
+		t.Fatalf("ошибка %v, ожидалась quotaError", err)
+	}
+	if calls != 0 {
+		t.Errorf("обращений %d: квота освободится после срока запроса, обращаться нельзя", calls)
+	}
+}
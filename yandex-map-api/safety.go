@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// refreshRejectedError — новое чтение таблицы отклонено защитой от опустошения
+type refreshRejectedError struct {
+	points, previous int
+	reason           string
+}
+
+func (e *refreshRejectedError) Error() string {
+	return fmt.Sprintf("в таблице %d точек вместо %d: %s", e.points, e.previous, e.reason)
+}
+
+// checkSafety — не похоже ли новое чтение на случайно удалённые строки. Первое чтение
+// (прежних данных нет) не проверяется: отдавать всё равно больше нечего.
+func (c *pointCache) checkSafety(prev, next *dataset) error {
+	if prev == nil {
+		return nil
+	}
+	n, p := len(next.Points), len(prev.Points)
+	if c.minPoints > 0 && n < c.minPoints && n < p {
+		return &refreshRejectedError{n, p, fmt.Sprintf("меньше MIN_POINTS_THRESHOLD=%d", c.minPoints)}
+	}
+	if c.minFraction > 0 && float64(n) < c.minFraction*float64(p) {
+		return &refreshRejectedError{n, p, fmt.Sprintf("меньше %.0f%% прежнего числа (MIN_POINTS_FRACTION)", c.minFraction*100)}
+	}
+	return nil
+}
+
+// forceRefresh — перечитать таблицу сейчас, не дожидаясь ttl и MIN_REFRESH_INTERVAL.
+// force — принять данные, даже если checkSafety их отклоняет.
+func (c *pointCache) forceRefresh(ctx context.Context, force bool) (*dataset, error) {
+	if err := c.acquireRefresh(ctx); err != nil {
+		return nil, err
+	}
+	defer func() { <-c.refreshing }()
+	return c.reload(ctx, force)
+}
+
+// refreshHandler — POST /api/refresh: перечитать таблицу немедленно (например, после массовой
+// правки). Токен — в заголовке Authorization: Bearer <REFRESH_TOKEN>. Если новое чтение
+// отклонено защитой, отвечаем 409; force=true — принять его всё равно.
+func refreshHandler(cache *pointCache, token string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method != http.MethodPost {
+			http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+			return
+		}
+		given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			http.Error(w, "Неверный токен", http.StatusUnauthorized)
+			return
+		}
+
+		force := r.URL.Query().Get("force") == "true"
+		data, err := cache.forceRefresh(r.Context(), force)
+		var rejected *refreshRejectedError
+		if errors.As(err, &rejected) {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"status": "rejected", "points": rejected.points, "previous": rejected.previous,
+				"error": err.Error() + "; чтобы принять данные, повторите с force=true",
+			})
+			return
+		}
+		if err != nil {
+			writeLoadError(w, err)
+			return
+		}
+
+		st := cache.status()
+		log.Printf("✅ Таблица перечитана по запросу: %d точек, поколение %d", len(data.Points), st.Generation)
+		if err := json.NewEncoder(w).Encode(map[string]interface{}{
+			"status": "ok", "points": len(data.Points), "generation": st.Generation,
+		}); err != nil {
+			log.Printf("❌ Ошибка отправки JSON: %v", err)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxSearchQuery — длина строки поиска q в символах
+const maxSearchQuery = 200
+
+// searchableFields — поля, по которым ищет q (имена как в JSON-ответе)
+var searchableFields = map[string]func(p LotPoint) []string{
+	"lotName":        func(p LotPoint) []string { return []string{p.LotName} },
+	"lotDescription": func(p LotPoint) []string { return []string{p.LotDescription} },
+	"title":          func(p LotPoint) []string { return []string{p.Title} },
+	"linkText":       func(p LotPoint) []string { return []string{p.LinkText} },
+	"category":       func(p LotPoint) []string { return []string{p.Category} },
+	"subcategory":    func(p LotPoint) []string { return []string{p.Subcategory} },
+	"region":         func(p LotPoint) []string { return []string{p.Region} },
+	"status":         func(p LotPoint) []string { return []string{p.Status} },
+	"id":             func(p LotPoint) []string { return []string{p.ID} },
+	"extras": func(p LotPoint) []string {
+		values := make([]string, 0, len(p.Extras))
+		for _, v := range p.Extras {
+			values = append(values, v)
+		}
+		return values
+	},
+}
+
+// defaultSearchFields — где ищет q без searchFields: название и описание, как раньше
+var defaultSearchFields = []string{"lotName", "lotDescription"}
+
+// pointSearch — поиск q: все слова запроса должны найтись хотя бы в одном из полей
+type pointSearch struct {
+	words  []string
+	fields []func(p LotPoint) []string
+}
+
+// parseSearch — разбирает q и searchFields (поля через запятую или all — все из searchableFields)
+func parseSearch(q, fieldList string) (*pointSearch, error) {
+	if strings.TrimSpace(q) == "" {
+		if fieldList != "" {
+			return nil, fmt.Errorf("searchFields без q")
+		}
+		return nil, nil
+	}
+	if len([]rune(q)) > maxSearchQuery {
+		return nil, fmt.Errorf("q длиннее %d символов", maxSearchQuery)
+	}
+
+	names := defaultSearchFields
+	switch strings.TrimSpace(fieldList) {
+	case "":
+	case "all":
+		names = searchableFieldNames()
+	default:
+		names = strings.Split(fieldList, ",")
+	}
+	s := &pointSearch{words: strings.Fields(normalizeSearch(q))}
+	seen := make(map[string]bool)
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		field, ok := searchableFields[name]
+		if !ok {
+			return nil, fmt.Errorf("неизвестное поле %q в searchFields (допустимо: all, %s)", name, strings.Join(searchableFieldNames(), ", "))
+		}
+		if !seen[name] {
+			seen[name] = true
+			s.fields = append(s.fields, field)
+		}
+	}
+	return s, nil
+}
+
+// searchableFieldNames — имена searchableFields по алфавиту
+func searchableFieldNames() []string {
+	names := make([]string, 0, len(searchableFields))
+	for name := range searchableFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// normalizeSearch — приводит текст к виду для сравнения: нижний регистр, ё как е,
+// одиночные пробелы. Применяется одинаково к запросу и ко всем полям.
+func normalizeSearch(s string) string {
+	s = strings.ToLower(strings.ReplaceAll(strings.ReplaceAll(s, "ё", "е"), "Ё", "Е"))
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// matches — каждое слово запроса есть хотя бы в одном из полей точки
+func (s *pointSearch) matches(p LotPoint) bool {
+	var text []string
+	for _, field := range s.fields {
+		for _, v := range field(p) {
+			if v != "" {
+				text = append(text, normalizeSearch(v))
+			}
+		}
+	}
+	for _, w := range s.words {
+		found := false
+		for _, t := range text {
+			if strings.Contains(t, w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// filter — точки, подходящие под запрос (points изменяется на месте)
+func (s *pointSearch) filter(points []LotPoint) []LotPoint {
+	kept := points[:0]
+	for _, p := range points {
+		if s.matches(p) {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
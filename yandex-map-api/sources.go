@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// Несколько таблиц с одинаковой структурой (EXTRA_SPREADSHEET_IDS), например региональные:
+// точки читаются из каждой и объединяются в один набор в порядке перечисления таблиц.
+
+// defaultSourceFetchConcurrency — сколько таблиц читать одновременно (SOURCE_FETCH_CONCURRENCY)
+const defaultSourceFetchConcurrency = 4
+
+// forSpreadsheet — загрузчик другой таблицы с теми же настройками
+func (l *sheetLoader) forSpreadsheet(id string) *sheetLoader {
+	c := *l
+	c.sheetID = id
+	c.sheetState = &sheetState{}
+	if l.gviz != nil {
+		c.gviz = newGvizSource(id, l.sheetName)
+	}
+	return &c
+}
+
+// multiSource — чтение нескольких таблиц параллельно, не больше concurrency одновременно.
+// Квота Sheets общая для всех таблиц: паузы по просьбе Google (sheetsQuota) соблюдают
+// все одновременные чтения, а после исчерпания квоты ещё не начатые таблицы не читаются.
+type multiSource struct {
+	loaders     []*sheetLoader
+	concurrency int
+
+	mu   sync.RWMutex
+	last []sourceStatus
+}
+
+// sourceStatus — итог последнего чтения одной таблицы (для /health/detail)
+type sourceStatus struct {
+	SpreadsheetID string    `json:"spreadsheetId"`
+	FetchedAt     time.Time `json:"fetchedAt"`
+	Points        int       `json:"points"`
+	Error         string    `json:"error,omitempty"`
+}
+
+// sourceResult — результат чтения одной таблицы
+type sourceResult struct {
+	data *dataset
+	err  error
+}
+
+// load — читает все таблицы и объединяет успешно прочитанные. Ошибка — только если
+// не прочиталась ни одна; частичный сбой попадает в журнал и /health/detail.
+func (m *multiSource) load(ctx context.Context) (*dataset, error) {
+	results := make([]sourceResult, len(m.loaders))
+	slots := make(chan struct{}, max(m.concurrency, 1))
+	var quotaMu sync.Mutex
+	var quotaErr error
+
+	var wg sync.WaitGroup
+	for i, l := range m.loaders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+			case <-ctx.Done():
+				results[i].err = ctx.Err()
+				return
+			}
+			defer func() { <-slots }()
+
+			quotaMu.Lock()
+			skip := quotaErr
+			quotaMu.Unlock()
+			if skip == nil {
+				skip = ctx.Err()
+			}
+			if skip != nil {
+				results[i].err = skip
+				return
+			}
+
+			data, err := l.load(ctx)
+			results[i] = sourceResult{data, err}
+			var qerr *quotaError
+			if errors.As(err, &qerr) {
+				quotaMu.Lock()
+				quotaErr = err
+				quotaMu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	now := time.Now()
+	statuses := make([]sourceStatus, len(results))
+	merged := &dataset{}
+	var firstErr error
+	failed := 0
+	for i, res := range results {
+		id := m.loaders[i].sheetID
+		statuses[i] = sourceStatus{SpreadsheetID: id, FetchedAt: now}
+		if res.err != nil {
+			log.Printf("❌ Таблица %s: %v", id, res.err)
+			statuses[i].Error = res.err.Error()
+			if firstErr == nil {
+				firstErr = res.err
+			}
+			failed++
+			continue
+		}
+		statuses[i].Points = len(res.data.Points)
+		merged.Points = append(merged.Points, res.data.Points...)
+		merged.Drafts = append(merged.Drafts, res.data.Drafts...)
+		for _, issue := range res.data.Issues {
+			issue.Spreadsheet = id // номера строк у каждой таблицы свои
+			merged.Issues = append(merged.Issues, issue)
+		}
+		merged.HasCreatedAt = merged.HasCreatedAt || res.data.HasCreatedAt
+	}
+
+	m.mu.Lock()
+	m.last = statuses
+	m.mu.Unlock()
+
+	if failed == len(results) {
+		return nil, firstErr
+	}
+	if failed > 0 {
+		log.Printf("⚠️ Прочитано таблиц: %d из %d, отдаём точки из доступных", len(results)-failed, len(results))
+	}
+	merged.Facets = computeFacets(merged.Points)
+	return merged, nil
+}
+
+// status — итоги последнего чтения по таблицам (nil — несколько таблиц не настроено)
+func (m *multiSource) status() []sourceStatus {
+	if m == nil {
+		return nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.last
+}
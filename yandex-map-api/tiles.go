@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/mvt"
+	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/orb/maptile"
+)
+
+// Ограничения тайлового эндпоинта
+const (
+	maxTileZoom = 22
+	// mvtLayerName — имя слоя с лотами в векторном тайле
+	mvtLayerName = "lots"
+)
+
+// tilesHandler — GET /api/tiles/{z}/{x}/{y}[?format=json|mvt]
+// Точки, попадающие в тайл XYZ (схема Web Mercator, как у Яндекс.Карт и OSM).
+// format=mvt — Mapbox Vector Tile с одним слоем "lots", по умолчанию — JSON-массив точек.
+func tilesHandler(cache *pointCache, access *accessControl) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		if r.Method != http.MethodGet {
+			http.Error(w, "Метод не поддерживается", http.StatusMethodNotAllowed)
+			return
+		}
+
+		tier, ok := access.tierFor(r)
+		if !ok {
+			writeUnknownKey(w)
+			return
+		}
+
+		tile, err := parseTilePath(strings.TrimPrefix(r.URL.Path, "/api/tiles/"))
+		if err != nil {
+			http.Error(w, "Некорректный тайл: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = "json"
+		}
+		if format != "json" && format != "mvt" {
+			http.Error(w, "Некорректный параметр format (допустимо: json, mvt)", http.StatusBadRequest)
+			return
+		}
+
+		data, err := cache.get(r.Context())
+		if err != nil {
+			writeLoadError(w, err)
+			return
+		}
+
+		bound := tile.Bound()
+		var points []LotPoint
+		for _, p := range data.Points {
+			if bound.Contains(orb.Point{p.Lon, p.Lat}) {
+				p.Raw = nil
+				points = append(points, p)
+			}
+		}
+
+		tier.project(points)
+
+		// Тайлы меняются не чаще обновления кэша
+		access.setCacheControl(w, r, int(cache.ttl.Seconds()))
+
+		if format == "mvt" {
+			body, err := encodeMVT(tile, points)
+			if err != nil {
+				log.Printf("❌ Ошибка кодирования MVT %d/%d/%d: %v", tile.Z, tile.X, tile.Y, err)
+				http.Error(w, "Ошибка сериализации", http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/vnd.mapbox-vector-tile")
+			w.Write(body)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if points == nil {
+			points = []LotPoint{}
+		}
+		if err := json.NewEncoder(w).Encode(points); err != nil {
+			log.Printf("❌ Ошибка отправки JSON: %v", err)
+		}
+	}
+}
+
+// parseTilePath — разбирает "z/x/y" (допускается расширение .mvt/.json у y)
+func parseTilePath(path string) (maptile.Tile, error) {
+	parts := strings.Split(path, "/")
+	if len(parts) != 3 {
+		return maptile.Tile{}, fmt.Errorf("ожидается /api/tiles/{z}/{x}/{y}")
+	}
+	if i := strings.IndexByte(parts[2], '.'); i >= 0 {
+		parts[2] = parts[2][:i]
+	}
+
+	var nums [3]uint64
+	for i, part := range parts {
+		n, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return maptile.Tile{}, fmt.Errorf("некорректное число %q", part)
+		}
+		nums[i] = n
+	}
+	if nums[0] > maxTileZoom {
+		return maptile.Tile{}, fmt.Errorf("масштаб больше %d", maxTileZoom)
+	}
+
+	tile := maptile.New(uint32(nums[1]), uint32(nums[2]), maptile.Zoom(nums[0]))
+	if !tile.Valid() {
+		return maptile.Tile{}, fmt.Errorf("x и y должны быть меньше 2^z")
+	}
+	return tile, nil
+}
+
+// encodeMVT — точки тайла как векторный тайл: один слой, свойства — поля лота с теми же
+// ключами, что в JSON-ответе (pointMap, после tier.project), без координат и пустых значений.
+// Вложенные значения (extras) — JSON-строкой: в MVT свойства только скалярные.
+// ID объекта — ID лота, если он числовой (так требует формат); свойство id — при любом ID.
+func encodeMVT(tile maptile.Tile, points []LotPoint) ([]byte, error) {
+	fc := geojson.NewFeatureCollection()
+	for _, p := range points {
+		f := geojson.NewFeature(orb.Point{p.Lon, p.Lat})
+		props := pointMap(p)
+		delete(props, outputName("lat"))
+		delete(props, outputName("lon"))
+		for k, v := range props {
+			switch v := v.(type) {
+			case nil:
+				delete(props, k)
+			case string:
+				if v == "" {
+					delete(props, k)
+				}
+			case float64:
+				if v == 0 {
+					delete(props, k)
+				}
+			case bool:
+				if !v {
+					delete(props, k)
+				}
+			case map[string]interface{}, []interface{}:
+				b, _ := json.Marshal(v)
+				props[k] = string(b)
+			}
+		}
+		f.Properties = props
+		if p.ID != "" {
+			f.ID = p.ID
+		}
+		fc.Append(f)
+	}
+
+	layers := mvt.NewLayers(map[string]*geojson.FeatureCollection{mvtLayerName: fc})
+	layers.ProjectToTile(tile)
+	return mvt.Marshal(layers)
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/paulmach/orb/encoding/mvt"
+	"github.com/paulmach/orb/maptile"
+)
+
+// tileTestCache — кэш с одной точкой, уже загруженный
+func tileTestCache(t *testing.T) *pointCache {
+	t.Helper()
+	cache := newPointCache(time.Minute, false, func(ctx context.Context) (*dataset, error) {
+		price := 1500000.0
+		return &dataset{Points: []LotPoint{
+			{ID: "7", Lat: 55.83, Lon: 49.07, LotName: "Лот", Link: "https://example.com/7", Priority: 2,
+				Status: "active", Region: "Татарстан", Price: &price, Currency: "RUB",
+				Extras: map[string]string{"Площадь": "12 га"}},
+			{ID: "A-1", Lat: 55.80, Lon: 49.10, LotName: "Без числового ID"},
+		}}, nil
+	})
+	if _, err := cache.get(context.Background()); err != nil {
+		t.Fatalf("загрузка: %v", err)
+	}
+	return cache
+}
+
+func TestTilesCacheHeaders(t *testing.T) {
+	access, err := parseAccessTiers(`{"public": {"fields": ["lotName"]}, "internal": {"keys": ["secret"], "fields": ["*"]}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		access    *accessControl
+		header    string // X-API-Key
+		query     string
+		wantCache string
+		wantVary  bool
+	}{
+		{"без ACCESS_TIERS", nil, "", "", "public, max-age=60", false},
+		{"без ключа", access, "", "", "public, max-age=60", true},
+		{"ключ в заголовке", access, "secret", "", "private, max-age=60", true},
+		{"ключ в apiKey", access, "", "?apiKey=secret", "private, max-age=60", true},
+	}
+	cache := tileTestCache(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/tiles/0/0/0"+tt.query, nil)
+			if tt.header != "" {
+				req.Header.Set("X-API-Key", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			tilesHandler(cache, tt.access)(rec, req)
+			if rec.Code != 200 {
+				t.Fatalf("статус %d: %s", rec.Code, rec.Body)
+			}
+			if got := rec.Header().Get("Cache-Control"); got != tt.wantCache {
+				t.Errorf("Cache-Control = %q, ожидалось %q", got, tt.wantCache)
+			}
+			vary := strings.Join(rec.Header().Values("Vary"), ",")
+			if strings.Contains(vary, "X-API-Key") != tt.wantVary {
+				t.Errorf("Vary = %q, X-API-Key ожидался: %v", vary, tt.wantVary)
+			}
+		})
+	}
+}
+
+// decodeTile — объекты слоя lots из ответа /api/tiles/0/0/0?format=mvt
+func decodeTile(t *testing.T, cache *pointCache, access *accessControl, key string) map[string]mvtFeature {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/api/tiles/0/0/0?format=mvt", nil)
+	if key != "" {
+		req.Header.Set("X-API-Key", key)
+	}
+	rec := httptest.NewRecorder()
+	tilesHandler(cache, access)(rec, req)
+	if rec.Code != 200 {
+		t.Fatalf("статус %d: %s", rec.Code, rec.Body)
+	}
+	layers, err := mvt.Unmarshal(rec.Body.Bytes())
+	if err != nil {
+		t.Fatalf("mvt.Unmarshal: %v", err)
+	}
+	layers.ProjectToWGS84(maptile.New(0, 0, 0))
+	features := make(map[string]mvtFeature)
+	for _, l := range layers {
+		if l.Name != mvtLayerName {
+			continue
+		}
+		for _, f := range l.Features {
+			features[f.Properties.MustString("lotName", "")] = mvtFeature{id: f.ID, props: f.Properties}
+		}
+	}
+	return features
+}
+
+type mvtFeature struct {
+	id    interface{}
+	props map[string]interface{}
+}
+
+func TestEncodeMVTProperties(t *testing.T) {
+	access, err := parseAccessTiers(`{"public": {"fields": ["lotName", "status"]}, "internal": {"keys": ["secret"], "fields": ["*"]}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache := tileTestCache(t)
+
+	full := decodeTile(t, cache, access, "secret")
+	lot := full["Лот"]
+	if id, ok := lot.id.(float64); !ok || id != 7 { // декодер orb отдаёт ID как float64
+		t.Errorf("ID объекта %v (%T), ожидалось 7", lot.id, lot.id)
+	}
+	want := map[string]interface{}{
+		"id": "7", "lotName": "Лот", "link": "https://example.com/7", "priority": 2.0,
+		"status": "active", "region": "Татарстан", "price": 1500000.0, "currency": "RUB",
+		"extras": `{"Площадь":"12 га"}`,
+	}
+	for k, v := range want {
+		if got := lot.props[k]; got != v {
+			t.Errorf("свойство %s = %v (%T), ожидалось %v", k, got, got, v)
+		}
+	}
+	for _, k := range []string{"lat", "lon", "lotDescription"} {
+		if _, ok := lot.props[k]; ok {
+			t.Errorf("лишнее свойство %s", k)
+		}
+	}
+	if other := full["Без числового ID"]; other.id != nil || other.props["id"] != "A-1" {
+		t.Errorf("нечисловой ID: ID объекта %v, свойство id %v", other.id, other.props["id"])
+	}
+
+	// Публичный уровень: только разрешённые поля (id не скрывается, как и в JSON), остальных нет вовсе
+	public := decodeTile(t, cache, access, "")["Лот"]
+	for k := range public.props {
+		if k != "id" && k != "lotName" && k != "status" {
+			t.Errorf("публичному уровню отдано свойство %s", k)
+		}
+	}
+	if public.props["status"] != "active" {
+		t.Errorf("status = %v, ожидалось active", public.props["status"])
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// titleTemplate — шаблон поля title (TITLE_TEMPLATE), например "{name} — {desc}".
+// Подстановки: {name}, {desc} (обрезается до descMax символов), {category}.
+type titleTemplate struct {
+	tmpl    string
+	descMax int
+}
+
+// titleTrimSet — разделители, которые срезаются по краям, если соседнее поле пустое
+const titleTrimSet = " \t—–-:|,;"
+
+func (t *titleTemplate) render(p LotPoint) string {
+	r := strings.NewReplacer(
+		"{name}", p.LotName,
+		"{desc}", truncateRunes(p.LotDescription, t.descMax),
+		"{category}", p.Category,
+	)
+	return strings.Trim(r.Replace(t.tmpl), titleTrimSet)
+}
+
+// truncateRunes — обрезает строку до max символов, добавляя многоточие (0 — не обрезать)
+func truncateRunes(s string, max int) string {
+	s = strings.TrimSpace(s)
+	if max <= 0 || utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	runes := []rune(s)
+	return strings.TrimSpace(string(runes[:max])) + "…"
+}
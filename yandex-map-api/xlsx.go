@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// xlsxSheetName — имя единственного листа в выгрузке
+const xlsxSheetName = "Points"
+
+// writeXLSX — отдаёт точки книгой Excel: строка заголовков (как в CSV) и по строке на точку.
+// Строки пишутся через StreamWriter — excelize держит в памяти только буфер и сбрасывает
+// остальное во временный файл, так что большие выгрузки не раздувают память.
+// Сам файл отправляется целиком после записи: формат zip не позволяет отдавать его по частям.
+func writeXLSX(w http.ResponseWriter, points []LotPoint) error {
+	f := excelize.NewFile()
+	defer f.Close()
+	if err := f.SetSheetName("Sheet1", xlsxSheetName); err != nil {
+		return err
+	}
+
+	sw, err := f.NewStreamWriter(xlsxSheetName)
+	if err != nil {
+		return err
+	}
+	// Ширина колонок: название, описание, заголовок и ссылка шире остальных
+	for col, width := range map[int]float64{3: 30, 4: 50, 5: 30, 6: 40} {
+		if err := sw.SetColWidth(col, col, width); err != nil {
+			return err
+		}
+	}
+
+	header := make([]interface{}, len(csvHeader))
+	for i, h := range outputHeader() {
+		header[i] = h
+	}
+	if err := sw.SetRow("A1", header); err != nil {
+		return err
+	}
+	for i, p := range points {
+		cell, err := excelize.CoordinatesToCellName(1, i+2)
+		if err != nil {
+			return err
+		}
+		row := []interface{}{
+			p.Lat, p.Lon, p.LotName, p.LotDescription, p.Title, p.Link,
+			p.Priority, p.Category, p.ThumbURL, p.ImageURL, max(p.Count, 1),
+		}
+		if err := sw.SetRow(cell, row); err != nil {
+			return err
+		}
+	}
+	if err := sw.Flush(); err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Set("Content-Disposition", `attachment; filename="points.xlsx"`)
+	_, err = f.WriteTo(w)
+	return err
+}